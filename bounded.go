@@ -0,0 +1,193 @@
+package btree
+
+import (
+	"cmp"
+	"container/list"
+	"iter"
+	"unsafe"
+)
+
+type EvictionPolicy int
+
+const (
+	// Evict the item with the smallest key
+	EvictSmallest EvictionPolicy = iota
+	// Evict the item with the largest key
+	EvictLargest
+	// Evict the least recently inserted or read item
+	EvictLRU
+	// Evict the item chosen by BoundedConfig.Victim
+	EvictCustom
+)
+
+// BoundedConfig describes the bounds of a BoundedBTree and how it evicts items
+type BoundedConfig[K cmp.Ordered, V any] struct {
+	// Maximum number of items. Zero means no limit
+	MaxItems int
+	// Approximate memory budget in bytes, as measured by Sizer. Zero means no limit
+	MaxBytes int
+	// Estimates the bytes used by an item. Defaults to the size of the item
+	// struct, which does not account for memory referenced by K or V
+	Sizer  func(k K, v V) int
+	Policy EvictionPolicy
+	// Picks the key to evict when Policy is EvictCustom. The tree passed in must
+	// not be modified. If the key is not in the tree, the item with the smallest
+	// key is evicted instead, so that the tree never stays over its bounds
+	Victim func(t *BTree[K, V]) K
+	// Called with every evicted item
+	OnEvict func(k K, v V)
+}
+
+// BoundedBTree is a BTree which evicts items whenever it grows past its bounds
+type BoundedBTree[K cmp.Ordered, V any] struct {
+	tree   *BTree[K, V]
+	config BoundedConfig[K, V]
+	bytes  int
+
+	// Recency list for EvictLRU, most recently used at the front
+	recency  *list.List
+	elements map[K]*list.Element
+}
+
+func NewBoundedBtree[K cmp.Ordered, V any](degree int, config BoundedConfig[K, V]) *BoundedBTree[K, V] {
+	if config.MaxItems < 0 || config.MaxBytes < 0 {
+		panic("Invalid bounds. Must not be negative")
+	}
+	if config.Policy == EvictCustom && config.Victim == nil {
+		panic("EvictCustom requires a Victim function")
+	}
+	if config.Sizer == nil {
+		itemSize := int(unsafe.Sizeof(Item[K, V]{}))
+		config.Sizer = func(K, V) int {
+			return itemSize
+		}
+	}
+
+	bt := BoundedBTree[K, V]{
+		tree:   NewBtree[K, V](degree),
+		config: config,
+	}
+	if config.Policy == EvictLRU {
+		bt.recency = list.New()
+		bt.elements = make(map[K]*list.Element)
+	}
+	return &bt
+}
+
+/*
+Insert key,value pair, then evict items until the tree is within its bounds.
+The inserted item itself may be evicted if the policy selects it
+*/
+func (t *BoundedBTree[K, V]) Insert(k K, v V) {
+	if old, found := t.tree.Replace(k, v); found {
+		t.bytes -= t.config.Sizer(k, old)
+	}
+	t.bytes += t.config.Sizer(k, v)
+	t.touch(k)
+
+	for t.overBound() && t.tree.Len() > 0 {
+		t.evict()
+	}
+}
+
+/*
+Attempt to get item with key k. Success is indicated by returned bool. Under
+EvictLRU a successful Get marks the item as recently used
+*/
+func (t *BoundedBTree[K, V]) Get(k K) (V, bool) {
+	v, found := t.tree.Get(k)
+	if found {
+		t.touch(k)
+	}
+	return v, found
+}
+
+/*
+Delete item with key k. Returns whether the key was found. Deleted items are
+not reported to OnEvict
+*/
+func (t *BoundedBTree[K, V]) Delete(k K) bool {
+	v, found := t.tree.Get(k)
+	if !found {
+		return false
+	}
+	t.remove(k, v)
+	return true
+}
+
+/*
+Returns the number of items in the tree
+*/
+func (t *BoundedBTree[K, V]) Len() int {
+	return t.tree.Len()
+}
+
+/*
+Returns the estimated memory used by the items in the tree, as measured by Sizer
+*/
+func (t *BoundedBTree[K, V]) Bytes() int {
+	return t.bytes
+}
+
+/*
+Returns an iterator over all key, value pairs in ascending key order. Iteration
+does not count as use for EvictLRU
+*/
+func (t *BoundedBTree[K, V]) All() iter.Seq2[K, V] {
+	return t.tree.All()
+}
+
+func (t *BoundedBTree[K, V]) overBound() bool {
+	if t.config.MaxItems > 0 && t.tree.Len() > t.config.MaxItems {
+		return true
+	}
+	return t.config.MaxBytes > 0 && t.bytes > t.config.MaxBytes
+}
+
+/*
+Evicts a single item according to the policy. The tree must not be empty
+*/
+func (t *BoundedBTree[K, V]) evict() {
+	var k K
+	switch t.config.Policy {
+	case EvictSmallest:
+		k, _, _ = t.tree.Min()
+	case EvictLargest:
+		k, _, _ = t.tree.Max()
+	case EvictLRU:
+		k = t.recency.Back().Value.(K)
+	case EvictCustom:
+		k = t.config.Victim(t.tree)
+	}
+
+	v, found := t.tree.Get(k)
+	if !found {
+		// Only a custom victim can be missing
+		k, v, _ = t.tree.Min()
+	}
+	t.remove(k, v)
+	if t.config.OnEvict != nil {
+		t.config.OnEvict(k, v)
+	}
+}
+
+func (t *BoundedBTree[K, V]) remove(k K, v V) {
+	t.tree.Delete(k)
+	t.bytes -= t.config.Sizer(k, v)
+	if t.recency != nil {
+		t.recency.Remove(t.elements[k])
+		delete(t.elements, k)
+	}
+}
+
+// Marks key k as most recently used
+func (t *BoundedBTree[K, V]) touch(k K) {
+	if t.recency == nil {
+		return
+	}
+	if elem, found := t.elements[k]; found {
+		t.recency.MoveToFront(elem)
+		return
+	}
+	t.elements[k] = t.recency.PushFront(k)
+}
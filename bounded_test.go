@@ -0,0 +1,170 @@
+package btree
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+func boundedKeys[K cmp.Ordered, V any](t *BoundedBTree[K, V]) []K {
+	var keys []K
+	for k := range t.All() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestBoundedEvictionPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   EvictionPolicy
+		expected []int
+		evicted  []int
+	}{
+		{"smallest", EvictSmallest, []int{5, 6, 7, 8, 9}, []int{0, 1, 2, 3, 4}},
+		{"largest", EvictLargest, []int{0, 1, 2, 3, 4}, []int{5, 6, 7, 8, 9}},
+		{"lru", EvictLRU, []int{4, 6, 7, 8, 9}, []int{0, 1, 2, 3, 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var evicted []int
+			tree := NewBoundedBtree(2, BoundedConfig[int, int]{
+				MaxItems: 5,
+				Policy:   test.policy,
+				OnEvict: func(k int, v int) {
+					evicted = append(evicted, k)
+				},
+			})
+
+			// Insert in an order where the largest key is inserted last
+			for _, k := range []int{0, 1, 2, 3, 5, 6, 7, 8, 4, 9} {
+				tree.Insert(k, k)
+			}
+
+			if keys := boundedKeys(tree); !slices.Equal(keys, test.expected) {
+				t.Errorf("Keys = %v; expected %v", keys, test.expected)
+			}
+			slices.Sort(evicted)
+			expectedEvicted := slices.Sorted(slices.Values(test.evicted))
+			if !slices.Equal(evicted, expectedEvicted) {
+				t.Errorf("Evicted = %v; expected %v", evicted, expectedEvicted)
+			}
+			if tree.Len() != 5 {
+				t.Errorf("Len() = %v; expected 5", tree.Len())
+			}
+		})
+	}
+}
+
+func TestBoundedLRUGetRefreshes(t *testing.T) {
+	tree := NewBoundedBtree(3, BoundedConfig[string, int]{
+		MaxItems: 2,
+		Policy:   EvictLRU,
+	})
+
+	tree.Insert("a", 1)
+	tree.Insert("b", 2)
+	tree.Get("a")
+	tree.Insert("c", 3)
+
+	if keys := boundedKeys(tree); !slices.Equal(keys, []string{"a", "c"}) {
+		t.Errorf("Keys = %v; expected [a c]", keys)
+	}
+
+	tree.Delete("a")
+	tree.Insert("d", 4)
+	tree.Insert("e", 5)
+	if keys := boundedKeys(tree); !slices.Equal(keys, []string{"d", "e"}) {
+		t.Errorf("Keys = %v; expected [d e]", keys)
+	}
+}
+
+func TestBoundedMaxBytes(t *testing.T) {
+	tree := NewBoundedBtree(2, BoundedConfig[int, string]{
+		MaxBytes: 10,
+		Sizer: func(k int, v string) int {
+			return len(v)
+		},
+		Policy: EvictSmallest,
+	})
+
+	tree.Insert(1, "aaaa")
+	tree.Insert(2, "bbbb")
+	if tree.Bytes() != 8 || tree.Len() != 2 {
+		t.Errorf("Bytes() = %v, Len() = %v; expected 8, 2", tree.Bytes(), tree.Len())
+	}
+
+	tree.Insert(3, "cccc")
+	if keys := boundedKeys(tree); !slices.Equal(keys, []int{2, 3}) {
+		t.Errorf("Keys = %v; expected [2 3]", keys)
+	}
+
+	// Replacing a value only counts the new size
+	tree.Insert(3, "c")
+	if tree.Bytes() != 5 {
+		t.Errorf("Bytes() = %v; expected 5", tree.Bytes())
+	}
+}
+
+func TestBoundedCustomVictim(t *testing.T) {
+	var evicted []int
+	tree := NewBoundedBtree(2, BoundedConfig[int, int]{
+		MaxItems: 3,
+		Policy:   EvictCustom,
+		Victim: func(t *BTree[int, int]) int {
+			// Evict the item with the smallest value
+			victim, smallest := 0, 0
+			first := true
+			for k, v := range t.All() {
+				if first || v < smallest {
+					victim, smallest, first = k, v, false
+				}
+			}
+			return victim
+		},
+		OnEvict: func(k int, v int) {
+			evicted = append(evicted, k)
+		},
+	})
+
+	tree.Insert(1, 30)
+	tree.Insert(2, 10)
+	tree.Insert(3, 20)
+	tree.Insert(4, 40)
+
+	if !slices.Equal(evicted, []int{2}) {
+		t.Errorf("Evicted = %v; expected [2]", evicted)
+	}
+}
+
+func TestBoundedMissingVictim(t *testing.T) {
+	var evicted []int
+	tree := NewBoundedBtree(2, BoundedConfig[int, int]{
+		MaxItems: 2,
+		Policy:   EvictCustom,
+		Victim:   func(t *BTree[int, int]) int { return -1 },
+		OnEvict: func(k int, v int) {
+			evicted = append(evicted, k)
+		},
+	})
+
+	for _, k := range []int{5, 3, 8, 1} {
+		tree.Insert(k, k)
+	}
+	if tree.Len() != 2 {
+		t.Errorf("Len() = %v; expected the tree to stay within 2 items", tree.Len())
+	}
+	if !slices.Equal(evicted, []int{3, 1}) {
+		t.Errorf("Evicted = %v; expected the smallest keys [3 1]", evicted)
+	}
+}
+
+func TestBoundedInvalidConfig(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("EvictCustom without Victim did not panic")
+		}
+	}()
+	NewBoundedBtree(2, BoundedConfig[int, int]{Policy: EvictCustom})
+}
@@ -0,0 +1,192 @@
+package btree
+
+import (
+	"cmp"
+	"iter"
+)
+
+// A buffered write. Deletes are buffered as tombstones, which hide the key in the main tree
+type bufferedValue[V any] struct {
+	value   V
+	deleted bool
+}
+
+/*
+BufferedBTree absorbs writes into a small buffer tree, and merges them into the
+main tree in bulk once the buffer is full. Deletes are buffered as tombstones.
+Reads consult the buffer first, then the main tree.
+
+A flush merges all buffered inserts into the main tree in a single ordered pass,
+visiting each node at most once and splitting overfull nodes into as many nodes
+as needed. Tombstones are applied one key at a time. A flush pays off when the
+buffer holds several keys per leaf of the main tree, as with large buffers or
+writes clustered in a few key ranges. Nodes of the buffer are reused across flushes
+*/
+type BufferedBTree[K cmp.Ordered, V any] struct {
+	tree   *BTree[K, V]
+	buffer *BTree[K, bufferedValue[V]]
+	limit  int
+}
+
+/*
+Creates an empty buffered btree of the given degree, which flushes once bufferSize
+writes to distinct keys are buffered
+*/
+func NewBufferedBtree[K cmp.Ordered, V any](degree, bufferSize int) *BufferedBTree[K, V] {
+	if bufferSize < 1 {
+		panic("Invalid buffer size. Must be positive")
+	}
+	return &BufferedBTree[K, V]{
+		tree:   NewBtree[K, V](degree),
+		buffer: NewBtreeWithFreeList(degree, NewFreeList[K, bufferedValue[V]](bufferSize/degree+1)),
+		limit:  bufferSize,
+	}
+}
+
+/*
+Insert key,value pair into the buffer. If k already exists, its value is replaced
+*/
+func (t *BufferedBTree[K, V]) Insert(k K, v V) {
+	t.buffer.Insert(k, bufferedValue[V]{value: v})
+	t.maybeFlush()
+}
+
+/*
+Delete key k by buffering a tombstone for it. Returns whether k was present. Unless
+k is buffered, this looks k up in the main tree
+*/
+func (t *BufferedBTree[K, V]) Delete(k K) bool {
+	if bv, found := t.buffer.Get(k); found {
+		if bv.deleted {
+			return false
+		}
+	} else if _, found := t.tree.Get(k); !found {
+		return false
+	}
+	t.buffer.Insert(k, bufferedValue[V]{deleted: true})
+	t.maybeFlush()
+	return true
+}
+
+/*
+Returns the value of key k. Success is indicated by returned bool
+*/
+func (t *BufferedBTree[K, V]) Get(k K) (V, bool) {
+	if bv, found := t.buffer.Get(k); found {
+		return bv.value, !bv.deleted
+	}
+	return t.tree.Get(k)
+}
+
+/*
+Returns the number of keys in the tree. Each buffered write is looked up in the
+main tree, so this costs a lookup per buffered key
+*/
+func (t *BufferedBTree[K, V]) Len() int {
+	length := t.tree.Len()
+	for k, bv := range t.buffer.All() {
+		_, inTree := t.tree.Get(k)
+		if bv.deleted && inTree {
+			length--
+		} else if !bv.deleted && !inTree {
+			length++
+		}
+	}
+	return length
+}
+
+/*
+Returns the number of buffered writes
+*/
+func (t *BufferedBTree[K, V]) Buffered() int {
+	return t.buffer.Len()
+}
+
+/*
+Flushes the buffer and returns the main tree. Changes made to it directly are
+visible through the buffered tree
+*/
+func (t *BufferedBTree[K, V]) Tree() *BTree[K, V] {
+	t.Flush()
+	return t.tree
+}
+
+func (t *BufferedBTree[K, V]) maybeFlush() {
+	if t.buffer.Len() >= t.limit {
+		t.Flush()
+	}
+}
+
+/*
+Merges all buffered writes into the main tree and empties the buffer
+*/
+func (t *BufferedBTree[K, V]) Flush() {
+	if t.buffer.Len() == 0 {
+		return
+	}
+	inserts := make([]Item[K, V], 0, t.buffer.Len())
+	for k, bv := range t.buffer.All() {
+		if bv.deleted {
+			t.tree.Delete(k)
+		} else {
+			inserts = append(inserts, Item[K, V]{key: k, value: bv.value})
+		}
+	}
+	t.tree.insertSorted(inserts)
+	t.buffer.clear()
+}
+
+/*
+Returns an iterator over all key, value pairs in ascending key order. The tree
+must not be modified while iterating
+*/
+func (t *BufferedBTree[K, V]) All() iter.Seq2[K, V] {
+	return mergeBuffered(t.tree.All(), t.buffer.All(), t.tree.cmp)
+}
+
+/*
+Returns an iterator over all key, value pairs with keys in the half-open range
+[from, to), in ascending key order. The tree must not be modified while iterating
+*/
+func (t *BufferedBTree[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return mergeBuffered(t.tree.Range(from, to), t.buffer.Range(from, to), t.tree.cmp)
+}
+
+/*
+Merges the items of tree with the buffered writes over them, in ascending key
+order. Buffered values replace those of the tree, and tombstones hide them
+*/
+func mergeBuffered[K cmp.Ordered, V any](tree iter.Seq2[K, V], buffer iter.Seq2[K, bufferedValue[V]], compare func(a, b K) int) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		nextTree, stopTree := iter.Pull2(tree)
+		defer stopTree()
+		nextBuffer, stopBuffer := iter.Pull2(buffer)
+		defer stopBuffer()
+
+		kt, vt, okT := nextTree()
+		kb, bv, okB := nextBuffer()
+		for okT || okB {
+			c := -1
+			if !okT {
+				c = 1
+			} else if okB {
+				c = compare(kt, kb)
+			}
+
+			if c < 0 {
+				if !yield(kt, vt) {
+					return
+				}
+				kt, vt, okT = nextTree()
+				continue
+			}
+			if !bv.deleted && !yield(kb, bv.value) {
+				return
+			}
+			if c == 0 {
+				kt, vt, okT = nextTree()
+			}
+			kb, bv, okB = nextBuffer()
+		}
+	}
+}
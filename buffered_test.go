@@ -0,0 +1,128 @@
+package btree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestBufferedBTreeOperations(t *testing.T) {
+	for _, bufferSize := range []int{1, 7, 64} {
+		t.Run(fmt.Sprintf("buffer %v", bufferSize), func(t *testing.T) {
+			tree := NewBufferedBtree[int, int](3, bufferSize)
+			model := make(map[int]int)
+			r := rand.New(rand.NewPCG(424242, uint64(bufferSize)))
+
+			for i := range 5000 {
+				k := r.IntN(400)
+				if r.IntN(3) == 0 {
+					_, inModel := model[k]
+					if deleted := tree.Delete(k); deleted != inModel {
+						t.Fatalf("Delete(%v) = %v; expected %v", k, deleted, inModel)
+					}
+					delete(model, k)
+				} else {
+					tree.Insert(k, i)
+					model[k] = i
+				}
+
+				if tree.Buffered() >= bufferSize {
+					t.Fatalf("buffer holds %v writes; expected a flush at %v", tree.Buffered(), bufferSize)
+				}
+				if i%100 == 0 {
+					if tree.Len() != len(model) {
+						t.Fatalf("Len() = %v; expected %v", tree.Len(), len(model))
+					}
+					for k := range 400 {
+						v, found := tree.Get(k)
+						if expected, inModel := model[k]; found != inModel || v != expected {
+							t.Fatalf("Get(%v) = (%v, %v); expected (%v, %v)", k, v, found, expected, inModel)
+						}
+					}
+				}
+			}
+
+			var expected []int
+			for k := range model {
+				expected = append(expected, k)
+			}
+			slices.Sort(expected)
+			var got []int
+			for k, v := range tree.All() {
+				if model[k] != v {
+					t.Fatalf("All() yielded %v = %v; expected %v", k, v, model[k])
+				}
+				got = append(got, k)
+			}
+			if !slices.Equal(got, expected) {
+				t.Errorf("All() yielded %v keys; expected %v", len(got), len(expected))
+			}
+
+			main := tree.Tree()
+			if tree.Buffered() != 0 {
+				t.Errorf("Tree() did not flush the buffer")
+			}
+			if err := main.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if main.Len() != len(model) {
+				t.Errorf("main tree holds %v keys after flushing; expected %v", main.Len(), len(model))
+			}
+		})
+	}
+}
+
+func TestBufferedBTreeRange(t *testing.T) {
+	tree := NewBufferedBtree[int, string](2, 100)
+	for i := range 10 {
+		tree.Insert(i*2, "tree")
+	}
+	tree.Flush()
+
+	// Buffered over the flushed keys: a replacement, a new key and two tombstones
+	tree.Insert(4, "buffer")
+	tree.Insert(5, "buffer")
+	tree.Delete(6)
+	tree.Delete(10)
+
+	tests := []struct {
+		from, to int
+		expected []string
+	}{
+		{0, 20, []string{"0=tree", "2=tree", "4=buffer", "5=buffer", "8=tree", "12=tree", "14=tree", "16=tree", "18=tree"}},
+		{3, 9, []string{"4=buffer", "5=buffer", "8=tree"}},
+		{6, 7, nil},
+		{5, 6, []string{"5=buffer"}},
+		{20, 30, nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for k, v := range tree.Range(test.from, test.to) {
+			got = append(got, fmt.Sprintf("%v=%v", k, v))
+		}
+		if !slices.Equal(got, test.expected) {
+			t.Errorf("Range(%v, %v) = %v; expected %v", test.from, test.to, got, test.expected)
+		}
+	}
+
+	count := 0
+	for range tree.All() {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Errorf("All() did not stop after 2 items")
+	}
+}
+
+func TestBufferedBTreeInvalidBufferSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewBufferedBtree(2, 0) did not panic")
+		}
+	}()
+	NewBufferedBtree[int, int](2, 0)
+}
@@ -0,0 +1,220 @@
+package btree
+
+import (
+	"slices"
+)
+
+/*
+Removes every item, giving the nodes to the free list if the tree has one
+*/
+func (t *BTree[K, V]) clear() {
+	var free func(n *Node[K, V])
+	free = func(n *Node[K, V]) {
+		for _, child := range n.children {
+			free(child)
+		}
+		t.freeNode(n)
+	}
+	if t.root != nil {
+		free(t.root)
+	}
+	t.root = nil
+	t.length = 0
+}
+
+/*
+Inserts batch, which must be in strictly ascending key order, replacing the values
+of existing keys. The tree is descended once for the whole batch
+*/
+func (t *BTree[K, V]) insertSorted(batch []Item[K, V]) {
+	if len(batch) == 0 {
+		return
+	}
+	if t.root == nil {
+		t.root = t.newNode()
+	}
+
+	separators, nodes := t.mergeSorted(t.root, batch)
+	for len(nodes) > 0 {
+		// The root overflowed into several nodes. They become the children of a new root
+		oldRoot := t.root
+		t.root = t.newNode()
+		separators, nodes = t.distribute(t.root, separators, append([]*Node[K, V]{oldRoot}, nodes...))
+		if t.tracer != nil {
+			t.tracer.GrowRoot(t.root.keys())
+		}
+	}
+}
+
+/*
+Merges batch, whose keys all fall in the subtree rooted at n, into it. If n
+overflows, it is split into several nodes. Returns the nodes following n, and
+the separators in front of each, for the parent to insert after n
+*/
+func (t *BTree[K, V]) mergeSorted(n *Node[K, V], batch []Item[K, V]) (items[K, V], []*Node[K, V]) {
+	n.invalidateHash()
+	if n.isLeaf() {
+		return t.mergeLeaf(n, batch)
+	}
+
+	// Children which overflowed, with the nodes and separators to insert after them
+	type overflow struct {
+		child      int
+		separators items[K, V]
+		nodes      []*Node[K, V]
+	}
+	var overflows []overflow
+	added := 0
+
+	for i := 0; i < len(batch); {
+		idx, found := t.find(n.items, batch[i].key)
+		if found {
+			n.items[idx].value = batch[i].value
+			i++
+			continue
+		}
+
+		// The part of the batch left of separator idx belongs to child idx
+		j := i + 1
+		for j < len(batch) && (idx == len(n.items) || t.cmp(batch[j].key, n.items[idx].key) < 0) {
+			j++
+		}
+		if separators, nodes := t.mergeSorted(n.children[idx], batch[i:j]); len(nodes) > 0 {
+			overflows = append(overflows, overflow{idx, separators, nodes})
+			added += len(nodes)
+		}
+		i = j
+	}
+	if len(overflows) == 0 {
+		return nil, nil
+	}
+
+	// Insert the new children in place if they fit, starting from the back so earlier indices stay valid
+	if len(n.items)+added <= t.maxItems() {
+		for o := len(overflows) - 1; o >= 0; o-- {
+			for s := len(overflows[o].nodes) - 1; s >= 0; s-- {
+				separator := overflows[o].separators[s]
+				n.items.insertAt(separator.key, separator.value, overflows[o].child)
+				n.children.insertAt(overflows[o].nodes[s], overflows[o].child+1)
+			}
+		}
+		return nil, nil
+	}
+
+	merged := make(items[K, V], 0, len(n.items)+added)
+	children := make([]*Node[K, V], 0, len(n.children)+added)
+	for idx, child := range n.children {
+		children = append(children, child)
+		if len(overflows) > 0 && overflows[0].child == idx {
+			for s, node := range overflows[0].nodes {
+				merged = append(merged, overflows[0].separators[s])
+				children = append(children, node)
+			}
+			overflows = overflows[1:]
+		}
+		if idx < len(n.items) {
+			merged = append(merged, n.items[idx])
+		}
+	}
+	return t.distribute(n, merged, children)
+}
+
+/*
+Merges batch into the leaf n, in place if the result fits. Returns the nodes
+following n and their separators, as mergeSorted
+*/
+func (t *BTree[K, V]) mergeLeaf(n *Node[K, V], batch []Item[K, V]) (items[K, V], []*Node[K, V]) {
+	// Replace the values of existing keys, and count the new ones
+	added := 0
+	for _, item := range batch {
+		if idx, found := t.find(n.items, item.key); found {
+			n.items[idx].value = item.value
+		} else {
+			added++
+		}
+	}
+	t.length += added
+	if added == 0 {
+		return nil, nil
+	}
+
+	size := len(n.items) + added
+	merged := n.items
+	if size > t.maxItems() {
+		merged = make(items[K, V], len(n.items), size)
+		copy(merged, n.items)
+	}
+	merged = slices.Grow(merged, added)[:size]
+
+	// Merge from the back, so that no item is overwritten before it has moved
+	a, b := len(n.items)-1, len(batch)-1
+	for w := size - 1; b >= 0; w-- {
+		c := -1
+		if a >= 0 {
+			c = t.cmp(merged[a].key, batch[b].key)
+		}
+		switch {
+		case c > 0:
+			merged[w] = merged[a]
+			a--
+		case c == 0:
+			merged[w] = merged[a]
+			a--
+			b--
+		default:
+			merged[w] = batch[b]
+			b--
+		}
+	}
+
+	if size <= t.maxItems() {
+		n.items = merged
+		return nil, nil
+	}
+	return t.distribute(n, merged, nil)
+}
+
+/*
+Fills n with items and children, splitting them over as few nodes as possible
+when they do not fit. Returns the nodes following n, and the separators in front
+of each
+*/
+func (t *BTree[K, V]) distribute(n *Node[K, V], merged items[K, V], children []*Node[K, V]) (items[K, V], []*Node[K, V]) {
+	// Each node holds at most maxItems items, and each but the last is followed by a separator
+	count := (len(merged) + t.maxItems() + 1) / (t.maxItems() + 1)
+	size, extra := (len(merged)-count+1)/count, (len(merged)-count+1)%count
+
+	var separators items[K, V]
+	var nodes []*Node[K, V]
+	start := 0
+	for c := range count {
+		end := start + size
+		if c < extra {
+			end++
+		}
+
+		node := n
+		if c > 0 {
+			node = t.newNode()
+			separators = append(separators, merged[start-1])
+			nodes = append(nodes, node)
+		}
+		node.items = append(node.items[:0], merged[start:end]...)
+		if children != nil {
+			node.children = append(node.children[:0], children[start:end+1]...)
+		}
+		if c > 0 && t.tracer != nil {
+			prev := n
+			if c > 1 {
+				prev = nodes[c-2]
+			}
+			t.tracer.Split(merged[start-1].key, prev.keys(), node.keys())
+		}
+		start = end + 1
+	}
+
+	// n may have shrunk, and must not keep the items and children it gave away alive
+	clear(n.items[len(n.items):cap(n.items)])
+	clear(n.children[len(n.children):cap(n.children)])
+	return separators, nodes
+}
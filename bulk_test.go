@@ -0,0 +1,65 @@
+package btree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestInsertSorted(t *testing.T) {
+	tests := []struct {
+		degree   int
+		existing int
+		batch    int
+	}{
+		{2, 0, 1},
+		{2, 0, 1000},
+		{3, 100, 1},
+		{3, 100, 5000},
+		{2, 1000, 50},
+		{8, 10_000, 300},
+		{8, 300, 10_000},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("degree %v existing %v batch %v", test.degree, test.existing, test.batch), func(t *testing.T) {
+			r := rand.New(rand.NewPCG(424242, uint64(test.batch)))
+			tree := NewBtree[int, int](test.degree)
+			model := make(map[int]int)
+			for range test.existing {
+				k := r.IntN(4 * (test.existing + test.batch))
+				tree.Insert(k, k)
+				model[k] = k
+			}
+			tree.RootHash()
+
+			keys := make(map[int]bool)
+			for len(keys) < test.batch {
+				keys[r.IntN(4*(test.existing+test.batch))] = true
+			}
+			var batch []Item[int, int]
+			for k := range keys {
+				batch = append(batch, Item[int, int]{key: k, value: -k})
+				model[k] = -k
+			}
+			slices.SortFunc(batch, func(a, b Item[int, int]) int { return a.key - b.key })
+
+			tree.insertSorted(batch)
+			if err := tree.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if tree.Len() != len(model) {
+				t.Errorf("Len() = %v; expected %v", tree.Len(), len(model))
+			}
+			for k, v := range tree.All() {
+				if model[k] != v {
+					t.Fatalf("key %v = %v; expected %v", k, v, model[k])
+				}
+			}
+			if cached := tree.RootHash(); cached != freshRootHash(tree) {
+				t.Errorf("cached root hash is stale after insertSorted")
+			}
+		})
+	}
+}
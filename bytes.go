@@ -0,0 +1,88 @@
+package btree
+
+import (
+	"iter"
+	"strings"
+)
+
+/*
+BytesBTree is a BTree keyed by byte slices, ordered as by bytes.Compare. Keys are
+stored as strings, whose ordering is the same bytewise ordering. Converting
+between the two copies, so keys passed in may be modified after the call, and
+keys handed out may be retained and modified freely
+*/
+type BytesBTree[V any] struct {
+	tree *BTree[string, V]
+}
+
+func NewBytesBtree[V any](degree int) *BytesBTree[V] {
+	return &BytesBTree[V]{tree: NewBtree[string, V](degree)}
+}
+
+/*
+Insert key k with value v. If k already exists, its value is replaced
+*/
+func (t *BytesBTree[V]) Insert(k []byte, v V) {
+	t.tree.Insert(string(k), v)
+}
+
+/*
+Returns the value of key k. Success is indicated by returned bool
+*/
+func (t *BytesBTree[V]) Get(k []byte) (V, bool) {
+	return t.tree.Get(string(k))
+}
+
+/*
+Delete key k. Returns whether k was present
+*/
+func (t *BytesBTree[V]) Delete(k []byte) bool {
+	return t.tree.Delete(string(k))
+}
+
+/*
+Returns the number of keys in the tree
+*/
+func (t *BytesBTree[V]) Len() int {
+	return t.tree.Len()
+}
+
+/*
+Returns an iterator over all key, value pairs in ascending key order. The tree
+must not be modified while iterating
+*/
+func (t *BytesBTree[V]) All() iter.Seq2[[]byte, V] {
+	return bytesKeys(t.tree.All(), func(string) bool { return true })
+}
+
+/*
+Returns an iterator over all key, value pairs with keys in the half-open range
+[from, to), in ascending key order. The tree must not be modified while iterating
+*/
+func (t *BytesBTree[V]) Range(from, to []byte) iter.Seq2[[]byte, V] {
+	return bytesKeys(t.tree.Range(string(from), string(to)), func(string) bool { return true })
+}
+
+/*
+Returns an iterator over all key, value pairs whose keys start with prefix, in
+ascending key order. Seeks to prefix, and stops at the first key without it. The
+tree must not be modified while iterating
+*/
+func (t *BytesBTree[V]) ScanPrefix(prefix []byte) iter.Seq2[[]byte, V] {
+	p := string(prefix)
+	return bytesKeys(t.tree.AscendFrom(p), func(k string) bool { return strings.HasPrefix(k, p) })
+}
+
+/*
+Converts the keys of seq to byte slices, stopping at the first key for which
+cont returns false
+*/
+func bytesKeys[V any](seq iter.Seq2[string, V], cont func(string) bool) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		for k, v := range seq {
+			if !cont(k) || !yield([]byte(k), v) {
+				return
+			}
+		}
+	}
+}
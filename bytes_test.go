@@ -0,0 +1,140 @@
+package btree
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func collectBytesKeys[V any](t *testing.T, seq func(func([]byte, V) bool)) []string {
+	t.Helper()
+	var keys []string
+	for k := range seq {
+		keys = append(keys, string(k))
+	}
+	return keys
+}
+
+func TestBytesBTreeOrdering(t *testing.T) {
+	random := rand.New(rand.NewPCG(424242, 1024))
+	btree := NewBytesBtree[int](2)
+	var keys [][]byte
+	for range 500 {
+		k := make([]byte, random.IntN(4))
+		for i := range k {
+			k[i] = byte(random.UintN(256))
+		}
+		keys = append(keys, k)
+		btree.Insert(k, len(k))
+	}
+	// Bytes at both ends of the range, which a signed comparison would misorder
+	for _, k := range [][]byte{{0x00}, {0xff}, {0x7f, 0x80}, {0x80}, {}} {
+		keys = append(keys, k)
+		btree.Insert(k, len(k))
+	}
+
+	slices.SortFunc(keys, bytes.Compare)
+	keys = slices.CompactFunc(keys, bytes.Equal)
+
+	var got [][]byte
+	for k := range btree.All() {
+		got = append(got, k)
+	}
+	if !slices.EqualFunc(got, keys, bytes.Equal) {
+		t.Errorf("All() = %x; expected %x", got, keys)
+	}
+	if btree.Len() != len(keys) {
+		t.Errorf("Len() = %v; expected %v", btree.Len(), len(keys))
+	}
+}
+
+func TestBytesBTreeDefensiveCopies(t *testing.T) {
+	btree := NewBytesBtree[string](2)
+	k := []byte("tenant/1/a")
+	btree.Insert(k, "a")
+
+	// Modifying the caller's slice must not change the stored key
+	k[len(k)-1] = 'z'
+	if _, found := btree.Get([]byte("tenant/1/a")); !found {
+		t.Errorf("stored key changed with the inserted slice")
+	}
+	if _, found := btree.Get(k); found {
+		t.Errorf("Get(%s) found a key which was never inserted", k)
+	}
+
+	// Modifying a yielded key must not change the stored key either
+	for k := range btree.All() {
+		k[0] = 'X'
+	}
+	if v, found := btree.Get([]byte("tenant/1/a")); !found || v != "a" {
+		t.Errorf("stored key changed with a yielded slice")
+	}
+}
+
+func TestBytesBTreeScanPrefix(t *testing.T) {
+	btree := NewBytesBtree[int](3)
+	keys := []string{"tenant/1", "tenant/1/a", "tenant/1/b", "tenant/12/a", "tenant/2/a", "tenant/2/b", "tenants", "user/1"}
+	for i, k := range keys {
+		btree.Insert([]byte(k), i)
+	}
+	btree.Insert([]byte{0xff, 0x00}, -1)
+	btree.Insert([]byte{0xff, 0xff, 0x01}, -1)
+
+	tests := []struct {
+		prefix   []byte
+		expected []string
+	}{
+		{[]byte("tenant/1/"), []string{"tenant/1/a", "tenant/1/b"}},
+		{[]byte("tenant/1"), []string{"tenant/1", "tenant/1/a", "tenant/1/b", "tenant/12/a"}},
+		{[]byte("tenant/"), []string{"tenant/1", "tenant/1/a", "tenant/1/b", "tenant/12/a", "tenant/2/a", "tenant/2/b"}},
+		{[]byte("tenant/3/"), nil},
+		{[]byte("user/1"), []string{"user/1"}},
+		{[]byte("zzz"), nil},
+		{[]byte{0xff, 0xff}, []string{"\xff\xff\x01"}},
+		{[]byte{0xff}, []string{"\xff\x00", "\xff\xff\x01"}},
+		{nil, append(slices.Clone(keys), "\xff\x00", "\xff\xff\x01")},
+	}
+
+	for _, test := range tests {
+		got := collectBytesKeys[int](t, btree.ScanPrefix(test.prefix))
+		if !slices.Equal(got, test.expected) {
+			t.Errorf("ScanPrefix(%q) = %q; expected %q", test.prefix, got, test.expected)
+		}
+	}
+
+	// Stopping early
+	count := 0
+	for range btree.ScanPrefix([]byte("tenant/")) {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Errorf("ScanPrefix() did not stop after 2 items")
+	}
+}
+
+func TestBytesBTreeRangeAndDelete(t *testing.T) {
+	btree := NewBytesBtree[int](2)
+	for i := range 20 {
+		btree.Insert([]byte(fmt.Sprintf("k%02d", i)), i)
+	}
+
+	got := collectBytesKeys[int](t, btree.Range([]byte("k05"), []byte("k08")))
+	if !slices.Equal(got, []string{"k05", "k06", "k07"}) {
+		t.Errorf("Range(k05, k08) = %v", got)
+	}
+
+	if !btree.Delete([]byte("k05")) || btree.Delete([]byte("k05")) {
+		t.Errorf("Delete(k05) did not report presence correctly")
+	}
+	if _, found := btree.Get([]byte("k05")); found || btree.Len() != 19 {
+		t.Errorf("k05 still present after Delete, or Len() = %v; expected 19", btree.Len())
+	}
+	if err := btree.tree.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"time"
+)
+
+// result holds the measurements of one store running one workload
+type result struct {
+	store string
+	load  time.Duration
+	// Time spent in the operations the store supports
+	elapsed   time.Duration
+	latencies [numOpKinds]histogram
+	// Operations the store could not perform, such as scans on a map
+	unsupported [numOpKinds]int
+	// Reads which found their key
+	hits int
+	// Items in the store at the end of the run
+	items int
+}
+
+/*
+bulkLoader is implemented by stores with a faster way to load many keys than
+inserting them one by one
+*/
+type bulkLoader interface {
+	load(keys []int64)
+}
+
+/*
+Loads the keys into s, then runs the operations one by one, timing each
+*/
+func runWorkload(s store, load []int64, ops []op) *result {
+	res := &result{store: s.name()}
+
+	start := time.Now()
+	if loader, ok := s.(bulkLoader); ok {
+		loader.load(load)
+	} else {
+		for i, k := range load {
+			s.put(k, int64(i))
+		}
+	}
+	res.load = time.Since(start)
+
+	for i, op := range ops {
+		opStart := time.Now()
+		switch op.kind {
+		case opRead:
+			if s.get(op.key) {
+				res.hits++
+			}
+		case opUpdate, opInsert:
+			s.put(op.key, int64(i))
+		case opDelete:
+			s.delete(op.key)
+		case opScan:
+			if _, ok := s.scan(op.key, op.n); !ok {
+				res.unsupported[op.kind]++
+				continue
+			}
+		}
+		latency := time.Since(opStart)
+		res.latencies[op.kind].record(latency)
+		res.elapsed += latency
+	}
+	res.items = s.len()
+	return res
+}
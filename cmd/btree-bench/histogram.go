@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Number of bits of each value kept exactly. Bucket bounds are within 1/2^subBucketBits of the value
+const subBucketBits = 4
+
+const subBuckets = 1 << subBucketBits
+
+/*
+histogram records latencies in log-linear buckets: values below subBuckets get a
+bucket each, larger values share a bucket with the values agreeing in their
+subBucketBits+1 most significant bits. Quantiles are accurate to about 6%
+*/
+type histogram struct {
+	counts [(64 - subBucketBits) * subBuckets]int64
+	count  int64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+func bucketOf(v int64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - subBucketBits - 1
+	return (shift+1)*subBuckets + int(v>>shift) - subBuckets
+}
+
+// Largest value falling into bucket idx
+func bucketUpperBound(idx int) int64 {
+	if idx < subBuckets {
+		return int64(idx)
+	}
+	shift := idx/subBuckets - 1
+	lower := int64(idx%subBuckets+subBuckets) << shift
+	return lower + 1<<shift - 1
+}
+
+func (h *histogram) record(d time.Duration) {
+	v := max(int64(d), 0)
+	h.counts[bucketOf(v)]++
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	h.max = max(h.max, v)
+	h.count++
+	h.sum += v
+}
+
+/*
+Returns the smallest recorded bucket bound which at least fraction q of the values
+are at or below. Returns 0 for an empty histogram
+*/
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q * float64(h.count)))
+	rank = min(max(rank, 1), h.count)
+
+	var seen int64
+	for idx, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			return time.Duration(min(bucketUpperBound(idx), h.max))
+		}
+	}
+	return time.Duration(h.max)
+}
+
+func (h *histogram) mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / h.count)
+}
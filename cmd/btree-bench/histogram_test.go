@@ -0,0 +1,53 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBucketBounds(t *testing.T) {
+	for _, v := range []int64{0, 1, 15, 16, 17, 31, 32, 33, 1000, 123456789, math.MaxInt64} {
+		idx := bucketOf(v)
+		if upper := bucketUpperBound(idx); upper < v {
+			t.Errorf("bucketUpperBound(bucketOf(%v)) = %v; expected at least %v", v, upper, v)
+		}
+		if idx > 0 && bucketUpperBound(idx-1) >= v {
+			t.Errorf("value %v also fits the previous bucket %v", v, idx-1)
+		}
+		if idx >= len(histogram{}.counts) {
+			t.Errorf("bucketOf(%v) = %v is out of range", v, idx)
+		}
+	}
+}
+
+func TestHistogramQuantiles(t *testing.T) {
+	var h histogram
+	if h.quantile(0.5) != 0 || h.mean() != 0 {
+		t.Errorf("empty histogram has non-zero quantile or mean")
+	}
+
+	r := rand.New(rand.NewPCG(1, 2))
+	values := make([]int64, 100_000)
+	for i := range values {
+		values[i] = int64(r.ExpFloat64() * 10_000)
+		h.record(time.Duration(values[i]))
+	}
+	slices.Sort(values)
+
+	for _, q := range []float64{0.5, 0.99, 0.999, 1} {
+		exact := values[int(math.Ceil(q*float64(len(values))))-1]
+		got := int64(h.quantile(q))
+		if got < exact || float64(got) > float64(exact)*(1+1.0/subBuckets)+1 {
+			t.Errorf("quantile(%v) = %v; expected within %v%% above %v", q, got, 100/subBuckets, exact)
+		}
+	}
+	if time.Duration(h.max) != h.quantile(1) || h.max != values[len(values)-1] {
+		t.Errorf("max = %v, quantile(1) = %v; expected %v", h.max, h.quantile(1), values[len(values)-1])
+	}
+	if h.min != values[0] {
+		t.Errorf("min = %v; expected %v", h.min, values[0])
+	}
+}
@@ -0,0 +1,184 @@
+// Command btree-bench runs YCSB style workloads against btrees of several
+// degrees, and optionally against a map and a sorted slice for comparison.
+//
+// Usage:
+//
+//	btree-bench [-workload a|b|c|d|e] [-mix read=95,insert=5] [-distribution zipfian]
+//	            [-records N] [-ops N] [-degrees 8,32,auto] [-compare]
+//
+// A run loads -records keys, then performs -ops operations drawn from the mix,
+// timing each one. Per operation latencies are reported as p50, p99 and p999.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+/*
+Runs the benchmark described by args. Returns the exit status
+*/
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("btree-bench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	preset := fs.String("workload", "a", "YCSB core workload to run: a, b, c, d or e")
+	mixFlag := fs.String("mix", "", "operation weights overriding the workload, such as read=90,update=5,scan=3,delete=2")
+	distribution := fs.String("distribution", "", "key distribution overriding the workload: "+strings.Join(distributions, ", "))
+	records := fs.Int("records", 100_000, "number of keys loaded before running the operations")
+	ops := fs.Int("ops", 1_000_000, "number of operations to run")
+	scanLen := fs.Int("scan-len", 100, "maximum number of items visited by a scan")
+	degrees := fs.String("degrees", "8,32,auto", "comma separated degrees of the btrees to run. auto picks the degree for the default node size")
+	compare := fs.Bool("compare", false, "also run the workload against a map and a sorted slice")
+	hashed := fs.Bool("hashed", true, "spread keys over the key space. Without it keys are inserted in ascending order")
+	seed := fs.Uint64("seed", 1, "seed of the workload generator")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	w, err := buildWorkload(*preset, *mixFlag, *distribution)
+	if err == nil && (*records < 0 || *ops < 0 || *scanLen < 1) {
+		err = errors.New("-records and -ops must not be negative, and -scan-len must be positive")
+	}
+	var degreeList []int
+	if err == nil {
+		degreeList, err = parseDegrees(*degrees)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "btree-bench: %v\n", err)
+		return 2
+	}
+	w.records, w.ops, w.maxScanLen, w.hashed, w.seed = *records, *ops, *scanLen, *hashed, *seed
+
+	load, opList, err := w.generate()
+	if err != nil {
+		fmt.Fprintf(stderr, "btree-bench: %v\n", err)
+		return 2
+	}
+
+	var stores []store
+	for _, degree := range degreeList {
+		s, err := newBTreeStore(degree)
+		if err != nil {
+			fmt.Fprintf(stderr, "btree-bench: %v\n", err)
+			return 2
+		}
+		stores = append(stores, s)
+	}
+	if *compare {
+		stores = append(stores, mapStore{}, &sliceStore{})
+	}
+
+	fmt.Fprintf(stdout, "records=%d ops=%d mix=%v distribution=%v hashed=%v seed=%d\n\n",
+		w.records, w.ops, w.mix, w.distribution, w.hashed, w.seed)
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "store\tload\tops/s\top\tcount\tp50\tp99\tp999\tmax\t")
+	for _, s := range stores {
+		printResult(tw, runWorkload(s, load, opList))
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintf(stderr, "btree-bench: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+/*
+Starts from the named preset and applies the mix and distribution overrides
+*/
+func buildWorkload(preset, mixOverride, distribution string) (workload, error) {
+	p, found := presets[preset]
+	if !found {
+		return workload{}, fmt.Errorf("unknown workload %q, expected one of a, b, c, d, e", preset)
+	}
+	if mixOverride != "" {
+		p.mix = mixOverride
+	}
+	if distribution != "" {
+		p.distribution = distribution
+	}
+
+	m, err := parseMix(p.mix)
+	if err != nil {
+		return workload{}, err
+	}
+	return workload{mix: m, distribution: p.distribution}, nil
+}
+
+// Parses a list such as "8,32,auto". auto is returned as 0
+func parseDegrees(s string) ([]int, error) {
+	var degrees []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "auto" {
+			degrees = append(degrees, 0)
+			continue
+		}
+		degree, err := strconv.Atoi(part)
+		if err != nil || degree < 2 {
+			return nil, fmt.Errorf("invalid degree %q, expected an integer of at least 2 or auto", part)
+		}
+		degrees = append(degrees, degree)
+	}
+	return degrees, nil
+}
+
+/*
+Prints one row per kind of operation the workload performed. The store, load time
+and throughput are only printed on the first row
+*/
+func printResult(w io.Writer, res *result) {
+	total := 0
+	for kind := range numOpKinds {
+		total += int(res.latencies[kind].count)
+	}
+	throughput := "-"
+	if res.elapsed > 0 {
+		throughput = formatRate(float64(total) / res.elapsed.Seconds())
+	}
+
+	first := true
+	for kind := range numOpKinds {
+		h := &res.latencies[kind]
+		unsupported := res.unsupported[kind]
+		if h.count == 0 && unsupported == 0 {
+			continue
+		}
+
+		prefix := "\t\t\t"
+		if first {
+			prefix = fmt.Sprintf("%s\t%v\t%s\t", res.store, res.load.Round(time.Microsecond), throughput)
+			first = false
+		}
+		if unsupported > 0 {
+			fmt.Fprintf(w, "%s%v\t%d\tunsupported\t\t\t\t\n", prefix, kind, unsupported)
+			continue
+		}
+		fmt.Fprintf(w, "%s%v\t%d\t%v\t%v\t%v\t%v\t\n", prefix, kind, h.count,
+			h.quantile(0.5), h.quantile(0.99), h.quantile(0.999), time.Duration(h.max))
+	}
+	if first {
+		fmt.Fprintf(w, "%s\t%v\t%s\t\t\t\t\t\t\t\n", res.store, res.load.Round(time.Microsecond), throughput)
+	}
+}
+
+func formatRate(perSecond float64) string {
+	switch {
+	case perSecond >= 1e6:
+		return fmt.Sprintf("%.2fM", perSecond/1e6)
+	case perSecond >= 1e3:
+		return fmt.Sprintf("%.1fk", perSecond/1e3)
+	default:
+		return fmt.Sprintf("%.0f", perSecond)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args     []string
+		status   int
+		contains []string
+	}{
+		{[]string{"-records", "100", "-ops", "1000", "-degrees", "4,auto"}, 0, []string{"btree/degree=4", "btree/degree=auto", "read", "update", "p999"}},
+		{[]string{"-records", "100", "-ops", "1000", "-workload", "e", "-compare", "-degrees", "8"}, 0, []string{"map", "unsupported", "sorted slice", "scan"}},
+		{[]string{"-records", "100", "-ops", "1000", "-mix", "delete=1", "-distribution", "sequential", "-degrees", "8"}, 0, []string{"delete"}},
+		{[]string{"-records", "0", "-ops", "0", "-degrees", "8"}, 0, []string{"btree/degree=8"}},
+		{[]string{"-workload", "z"}, 2, nil},
+		{[]string{"-mix", "write=1"}, 2, nil},
+		{[]string{"-distribution", "gaussian"}, 2, nil},
+		{[]string{"-degrees", "1"}, 2, nil},
+		{[]string{"-scan-len", "0"}, 2, nil},
+	}
+
+	for _, test := range tests {
+		var stdout, stderr strings.Builder
+		status := run(test.args, &stdout, &stderr)
+		if status != test.status {
+			t.Errorf("run(%v) = %v; expected %v: %v", test.args, status, test.status, stderr.String())
+		}
+		for _, expected := range test.contains {
+			if !strings.Contains(stdout.String(), expected) {
+				t.Errorf("run(%v) printed\n%v\nexpected it to contain %q", test.args, stdout.String(), expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/push-and-pray/btree"
+)
+
+/*
+store is a key-value store under benchmark
+*/
+type store interface {
+	name() string
+	get(k int64) bool
+	put(k, v int64)
+	delete(k int64)
+	// Visits up to n items with keys at or after from. Returns the number visited,
+	// or false if the store cannot scan in key order
+	scan(from int64, n int) (int, bool)
+	len() int
+}
+
+// Scans fold the keys and values they visit into scanSink, so that reading them cannot be optimized away
+var scanSink int64
+
+type btreeStore struct {
+	tree  *btree.BTree[int64, int64]
+	label string
+}
+
+func newBTreeStore(degree int) (*btreeStore, error) {
+	var opts []btree.Option
+	if degree != 0 {
+		opts = append(opts, btree.WithDegree(degree))
+	}
+	tree, err := btree.New[int64, int64](opts...)
+	if err != nil {
+		return nil, err
+	}
+	label := "btree/degree=auto"
+	if degree != 0 {
+		label = fmt.Sprintf("btree/degree=%d", degree)
+	}
+	return &btreeStore{tree: tree, label: label}, nil
+}
+
+func (s *btreeStore) name() string {
+	return s.label
+}
+
+func (s *btreeStore) get(k int64) bool {
+	_, found := s.tree.Get(k)
+	return found
+}
+
+func (s *btreeStore) put(k, v int64) {
+	s.tree.Insert(k, v)
+}
+
+func (s *btreeStore) delete(k int64) {
+	s.tree.Delete(k)
+}
+
+func (s *btreeStore) scan(from int64, n int) (int, bool) {
+	visited := 0
+	for k, v := range s.tree.AscendFrom(from) {
+		scanSink += k ^ v
+		visited++
+		if visited == n {
+			break
+		}
+	}
+	return visited, true
+}
+
+func (s *btreeStore) len() int {
+	return s.tree.Len()
+}
+
+/*
+mapStore is the unordered baseline. It cannot scan in key order without sorting
+the whole map, so scans are reported as unsupported
+*/
+type mapStore map[int64]int64
+
+func (s mapStore) name() string {
+	return "map"
+}
+
+func (s mapStore) get(k int64) bool {
+	_, found := s[k]
+	return found
+}
+
+func (s mapStore) put(k, v int64) {
+	s[k] = v
+}
+
+func (s mapStore) delete(k int64) {
+	delete(s, k)
+}
+
+func (s mapStore) scan(from int64, n int) (int, bool) {
+	return 0, false
+}
+
+func (s mapStore) len() int {
+	return len(s)
+}
+
+/*
+sliceStore keeps parallel sorted slices of keys and values. Lookups are a binary
+search, inserts and deletes shift everything after the key
+*/
+type sliceStore struct {
+	keys   []int64
+	values []int64
+}
+
+func (s *sliceStore) name() string {
+	return "sorted slice"
+}
+
+// Sorts the keys once instead of shifting the slices for every insert
+func (s *sliceStore) load(keys []int64) {
+	type pair struct{ key, value int64 }
+	pairs := make([]pair, len(keys))
+	for i, k := range keys {
+		pairs[i] = pair{k, int64(i)}
+	}
+	slices.SortStableFunc(pairs, func(a, b pair) int { return cmp.Compare(a.key, b.key) })
+
+	// Keep the last value of duplicate keys, as inserting one by one would
+	for i, p := range pairs {
+		if i+1 < len(pairs) && pairs[i+1].key == p.key {
+			continue
+		}
+		s.keys = append(s.keys, p.key)
+		s.values = append(s.values, p.value)
+	}
+}
+
+func (s *sliceStore) get(k int64) bool {
+	_, found := slices.BinarySearch(s.keys, k)
+	return found
+}
+
+func (s *sliceStore) put(k, v int64) {
+	idx, found := slices.BinarySearch(s.keys, k)
+	if found {
+		s.values[idx] = v
+		return
+	}
+	s.keys = slices.Insert(s.keys, idx, k)
+	s.values = slices.Insert(s.values, idx, v)
+}
+
+func (s *sliceStore) delete(k int64) {
+	idx, found := slices.BinarySearch(s.keys, k)
+	if !found {
+		return
+	}
+	s.keys = slices.Delete(s.keys, idx, idx+1)
+	s.values = slices.Delete(s.values, idx, idx+1)
+}
+
+func (s *sliceStore) scan(from int64, n int) (int, bool) {
+	idx, _ := slices.BinarySearch(s.keys, from)
+	end := min(idx+n, len(s.keys))
+	visited := 0
+	for i, k := range s.keys[idx:end] {
+		scanSink += k ^ s.values[idx+i]
+		visited++
+	}
+	return visited, true
+}
+
+func (s *sliceStore) len() int {
+	return len(s.keys)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestStoresAgree(t *testing.T) {
+	m, _ := parseMix("read=40,update=15,insert=15,scan=15,delete=15")
+	w := workload{records: 500, ops: 5000, mix: m, distribution: "uniform", maxScanLen: 20, hashed: true, seed: 5}
+	load, ops, err := w.generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := newBTreeStore(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []*result{
+		runWorkload(tree, load, ops),
+		runWorkload(mapStore{}, load, ops),
+		runWorkload(&sliceStore{}, load, ops),
+	}
+	for _, res := range results[1:] {
+		if res.hits != results[0].hits || res.items != results[0].items {
+			t.Errorf("%v had %v hits and %v items; %v had %v and %v",
+				res.store, res.hits, res.items, results[0].store, results[0].hits, results[0].items)
+		}
+	}
+	if results[1].unsupported[opScan] == 0 || results[0].unsupported[opScan] != 0 {
+		t.Errorf("expected only the map to report unsupported scans")
+	}
+
+	// Scans visit the same number of items in both ordered stores
+	slice := &sliceStore{}
+	slice.load(load)
+	for _, op := range ops[:200] {
+		if op.kind != opScan {
+			continue
+		}
+		fromTree, _ := tree.scan(op.key, op.n)
+		fromSlice, _ := slice.scan(op.key, op.n)
+		if fromTree != fromSlice {
+			t.Errorf("scan(%v, %v) visited %v items in the tree and %v in the slice", op.key, op.n, fromTree, fromSlice)
+		}
+	}
+}
+
+func TestSliceLoadDuplicates(t *testing.T) {
+	s := &sliceStore{}
+	s.load([]int64{3, 1, 3, 2})
+	if len(s.keys) != 3 || s.values[2] != 2 {
+		t.Errorf("load() = %v, %v; expected 3 keys with the last value of key 3", s.keys, s.values)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+type opKind int
+
+const (
+	opRead opKind = iota
+	opUpdate
+	opInsert
+	opScan
+	opDelete
+	numOpKinds
+)
+
+var opNames = [numOpKinds]string{"read", "update", "insert", "scan", "delete"}
+
+func (k opKind) String() string {
+	return opNames[k]
+}
+
+type op struct {
+	kind opKind
+	key  int64
+	// Number of items to visit, for scans
+	n int
+}
+
+// mix holds the relative weight of each kind of operation
+type mix [numOpKinds]float64
+
+/*
+Parses a mix such as "read=95,insert=5". Weights need not add up to anything in
+particular, they are normalized
+*/
+func parseMix(s string) (mix, error) {
+	var m mix
+	total := 0.0
+	for _, part := range strings.Split(s, ",") {
+		name, weight, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			return m, fmt.Errorf("invalid mix entry %q, expected op=weight", part)
+		}
+		kind := -1
+		for k, opName := range opNames {
+			if opName == name {
+				kind = k
+			}
+		}
+		if kind < 0 {
+			return m, fmt.Errorf("unknown operation %q, expected one of %v", name, strings.Join(opNames[:], ", "))
+		}
+		w, err := strconv.ParseFloat(weight, 64)
+		if err != nil || w < 0 || math.IsInf(w, 0) {
+			return m, fmt.Errorf("invalid weight %q for %v", weight, name)
+		}
+		m[kind] += w
+		total += w
+	}
+	if total == 0 {
+		return m, fmt.Errorf("mix %q has no operations", s)
+	}
+	for k := range m {
+		m[k] /= total
+	}
+	return m, nil
+}
+
+func (m mix) String() string {
+	var parts []string
+	for k, w := range m {
+		if w > 0 {
+			parts = append(parts, fmt.Sprintf("%v=%g", opKind(k), math.Round(w*1000)/10))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+/*
+Presets modeled on the YCSB core workloads A to E. Workload F's read-modify-write
+is left out, since a tree update already is a single descent, which makes it the
+same as workload A
+*/
+var presets = map[string]struct {
+	mix          string
+	distribution string
+}{
+	"a": {"read=50,update=50", "zipfian"},
+	"b": {"read=95,update=5", "zipfian"},
+	"c": {"read=100", "zipfian"},
+	"d": {"read=95,insert=5", "latest"},
+	"e": {"scan=95,insert=5", "zipfian"},
+}
+
+var distributions = []string{"uniform", "zipfian", "latest", "sequential"}
+
+/*
+workload describes a benchmark run: records keys are loaded, then ops operations
+are drawn from mix, with keys chosen by the distribution
+*/
+type workload struct {
+	records      int
+	ops          int
+	mix          mix
+	distribution string
+	maxScanLen   int
+	// Spread key numbers over the key space by hashing them, instead of using them
+	// as keys directly. Without hashing inserts always go to the right edge of the tree
+	hashed bool
+	seed   uint64
+}
+
+/*
+Generates the keys to load and the operations to run. Operations are generated
+up front so that choosing keys does not count towards their latency
+*/
+func (w workload) generate() ([]int64, []op, error) {
+	r := rand.New(rand.NewPCG(w.seed, w.seed^0x9e3779b97f4a7c15))
+
+	load := make([]int64, w.records)
+	for i := range load {
+		load[i] = w.key(int64(i))
+	}
+
+	var choose func(inserted int64) int64
+	switch w.distribution {
+	case "uniform":
+		choose = func(inserted int64) int64 { return r.Int64N(inserted) }
+	case "zipfian":
+		z := newZipfian(int64(max(w.records, 1)))
+		choose = func(inserted int64) int64 { return min(z.next(r), inserted-1) }
+	case "latest":
+		z := newZipfian(int64(max(w.records, 1)))
+		choose = func(inserted int64) int64 { return max(inserted-1-z.next(r), 0) }
+	case "sequential":
+		next := int64(0)
+		choose = func(inserted int64) int64 {
+			keynum := next % inserted
+			next++
+			return keynum
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown distribution %q, expected one of %v", w.distribution, strings.Join(distributions, ", "))
+	}
+
+	// Cumulative weights for picking operations
+	var cumulative [numOpKinds]float64
+	sum := 0.0
+	last := opRead
+	for k, weight := range w.mix {
+		sum += weight
+		cumulative[k] = sum
+		if weight > 0 {
+			last = opKind(k)
+		}
+	}
+
+	inserted := int64(w.records)
+	ops := make([]op, w.ops)
+	for i := range ops {
+		u := r.Float64() * sum
+		kind := last
+		for k := range numOpKinds {
+			if u < cumulative[k] {
+				kind = k
+				break
+			}
+		}
+
+		switch {
+		case kind == opInsert:
+			ops[i] = op{kind: kind, key: w.key(inserted)}
+			inserted++
+		case inserted == 0:
+			// Nothing to choose from yet, every key misses
+			ops[i] = op{kind: kind, key: w.key(0)}
+		default:
+			ops[i] = op{kind: kind, key: w.key(choose(inserted))}
+		}
+		if kind == opScan {
+			ops[i].n = 1 + r.IntN(max(w.maxScanLen, 1))
+		}
+	}
+	return load, ops, nil
+}
+
+// Maps a key number to a key
+func (w workload) key(keynum int64) int64 {
+	if !w.hashed {
+		return keynum
+	}
+	h := fnv.New64a()
+	var b [8]byte
+	for i := range b {
+		b[i] = byte(keynum >> (8 * i))
+	}
+	h.Write(b[:])
+	return int64(h.Sum64() >> 1)
+}
+
+/*
+zipfian draws ranks in [0, n) with the probability of rank i proportional to
+1/(i+1)^theta, using the method of Gray et al., "Quickly Generating Billion-Record
+Synthetic Databases", as YCSB does. Rank 0 is the most popular
+*/
+type zipfian struct {
+	n     float64
+	theta float64
+	alpha float64
+	zetan float64
+	eta   float64
+}
+
+// Skew of YCSB's zipfian distribution
+const zipfianTheta = 0.99
+
+func newZipfian(n int64) *zipfian {
+	zetan := 0.0
+	for i := int64(1); i <= n; i++ {
+		zetan += 1 / math.Pow(float64(i), zipfianTheta)
+	}
+	zeta2 := 1 + 1/math.Pow(2, zipfianTheta)
+	return &zipfian{
+		n:     float64(n),
+		theta: zipfianTheta,
+		alpha: 1 / (1 - zipfianTheta),
+		zetan: zetan,
+		eta:   (1 - math.Pow(2/float64(n), 1-zipfianTheta)) / (1 - zeta2/zetan),
+	}
+}
+
+func (z *zipfian) next(r *rand.Rand) int64 {
+	u := r.Float64()
+	uz := u * z.zetan
+	if uz < 1 {
+		return 0
+	}
+	if uz < 1+math.Pow(0.5, z.theta) {
+		return 1
+	}
+	rank := int64(z.n * math.Pow(z.eta*u-z.eta+1, z.alpha))
+	return min(rank, int64(z.n)-1)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected mix
+		err      bool
+	}{
+		{"read=100", mix{opRead: 1}, false},
+		{"read=95, insert=5", mix{opRead: 0.95, opInsert: 0.05}, false},
+		{"read=1,update=1,scan=1,delete=1", mix{opRead: 0.25, opUpdate: 0.25, opScan: 0.25, opDelete: 0.25}, false},
+		{"read=1,read=1,insert=2", mix{opRead: 0.5, opInsert: 0.5}, false},
+		{"read", mix{}, true},
+		{"write=5", mix{}, true},
+		{"read=-1", mix{}, true},
+		{"read=x", mix{}, true},
+		{"read=0", mix{}, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseMix(test.s)
+		if (err != nil) != test.err {
+			t.Errorf("parseMix(%q) error = %v; expected error %v", test.s, err, test.err)
+			continue
+		}
+		for k := range got {
+			if math.Abs(got[k]-test.expected[k]) > 1e-9 {
+				t.Errorf("parseMix(%q) = %v; expected %v", test.s, got, test.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestGenerateMix(t *testing.T) {
+	m, _ := parseMix("read=50,update=20,insert=10,scan=15,delete=5")
+	w := workload{records: 1000, ops: 100_000, mix: m, distribution: "uniform", maxScanLen: 10, seed: 1}
+	load, ops, err := w.generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(load) != 1000 || len(ops) != 100_000 {
+		t.Fatalf("generate() = %v keys and %v ops; expected 1000 and 100000", len(load), len(ops))
+	}
+
+	var counts [numOpKinds]int
+	for _, op := range ops {
+		counts[op.kind]++
+		if op.kind == opScan && (op.n < 1 || op.n > 10) {
+			t.Errorf("scan of %v items; expected between 1 and 10", op.n)
+		}
+	}
+	for k, count := range counts {
+		if share := float64(count) / float64(len(ops)); math.Abs(share-m[k]) > 0.01 {
+			t.Errorf("%v share = %v; expected %v", opKind(k), share, m[k])
+		}
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	m, _ := parseMix("read=50,insert=50")
+	w := workload{records: 100, ops: 1000, mix: m, distribution: "zipfian", hashed: true, seed: 7}
+	_, first, _ := w.generate()
+	_, second, _ := w.generate()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("op %v differs between runs with the same seed: %v and %v", i, first[i], second[i])
+		}
+	}
+}
+
+/*
+Generates read keys with the given distribution and returns how often each key
+number was chosen. Keys are not hashed, so keys are key numbers
+*/
+func keyCounts(t *testing.T, distribution string, mixSpec string) (map[int64]int, []op) {
+	t.Helper()
+	m, _ := parseMix(mixSpec)
+	w := workload{records: 1000, ops: 100_000, mix: m, distribution: distribution, seed: 3}
+	_, ops, err := w.generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[int64]int)
+	for _, op := range ops {
+		if op.kind == opRead {
+			counts[op.key]++
+		}
+	}
+	return counts, ops
+}
+
+func TestDistributions(t *testing.T) {
+	uniform, _ := keyCounts(t, "uniform", "read=1")
+	if len(uniform) < 990 || uniform[0] > 200 {
+		t.Errorf("uniform chose %v distinct keys and key 0 %v times; expected about 1000 and 100", len(uniform), uniform[0])
+	}
+
+	zipf, _ := keyCounts(t, "zipfian", "read=1")
+	if zipf[0] < 5*zipf[10] || zipf[0] < 8000 {
+		t.Errorf("zipfian chose key 0 %v times and key 10 %v times; expected a heavy skew towards 0", zipf[0], zipf[10])
+	}
+
+	sequential, ops := keyCounts(t, "sequential", "read=1")
+	for i, op := range ops[:2500] {
+		if op.key != int64(i%1000) {
+			t.Fatalf("sequential op %v read key %v; expected %v", i, op.key, i%1000)
+		}
+	}
+	if len(sequential) != 1000 {
+		t.Errorf("sequential chose %v distinct keys; expected 1000", len(sequential))
+	}
+
+	// With inserts, latest favors the most recently inserted keys
+	_, ops = keyCounts(t, "latest", "read=50,insert=50")
+	recent := 0
+	inserted := int64(1000)
+	reads := 0
+	for _, op := range ops {
+		switch op.kind {
+		case opInsert:
+			if op.key != inserted {
+				t.Fatalf("insert of key %v; expected the next key %v", op.key, inserted)
+			}
+			inserted++
+		case opRead:
+			if op.key >= inserted {
+				t.Fatalf("read of key %v which is not inserted yet", op.key)
+			}
+			if op.key >= inserted-10 {
+				recent++
+			}
+			reads++
+		}
+	}
+	if float64(recent) < 0.3*float64(reads) {
+		t.Errorf("latest read one of the 10 newest keys %v of %v times; expected a heavy skew", recent, reads)
+	}
+
+	m, _ := parseMix("read=1")
+	if _, _, err := (workload{mix: m, distribution: "gaussian"}).generate(); err == nil {
+		t.Errorf("generate() with unknown distribution succeeded")
+	}
+}
+
+func TestGenerateEmpty(t *testing.T) {
+	m, _ := parseMix("read=50,delete=50")
+	w := workload{records: 0, ops: 100, mix: m, distribution: "zipfian", seed: 1}
+	if _, _, err := w.generate(); err != nil {
+		t.Errorf("generate() without records = %v", err)
+	}
+}
+
+func TestZipfianRange(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 1))
+	for _, n := range []int64{1, 2, 3, 100} {
+		z := newZipfian(n)
+		for range 10_000 {
+			if rank := z.next(r); rank < 0 || rank >= n {
+				t.Fatalf("zipfian over %v items drew rank %v", n, rank)
+			}
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Command btree-repl is an interactive shell for exploring how a btree changes
+// as items are inserted and deleted.
+//
+// Usage:
+//
+//	btree-repl [-degree N] [-verbose]
+//
+// Every command re-renders the tree. In verbose mode each split, merge, steal and
+// change of the root's height is narrated as it happens.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/push-and-pray/btree"
+)
+
+const help = `commands:
+  insert KEY VALUE   insert or replace an item
+  delete KEY         delete an item
+  get KEY            print the value of a key
+  range [FROM [TO]]  print the items in [FROM, TO)
+  undo               restore the tree as it was before the last insert or delete
+  show               print the tree
+  verbose on|off     narrate splits, merges and steals
+  help               print this help
+  quit               leave the REPL
+`
+
+var errQuit = errors.New("quit")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+/*
+Runs the REPL until stdin ends or quit is entered. Returns the exit status
+*/
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("btree-repl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	degree := fs.Int("degree", 2, "degree of the tree")
+	verbose := fs.Bool("verbose", false, "narrate splits, merges and steals")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *degree < 2 {
+		fmt.Fprintf(stderr, "btree-repl: degree must be at least 2, got %d\n", *degree)
+		return 2
+	}
+
+	r := newREPL(*degree, stdout)
+	r.narrator.enabled = *verbose
+	fmt.Fprintf(stdout, "btree of degree %d. Type help for a list of commands\n", *degree)
+
+	scanner := bufio.NewScanner(stdin)
+	for {
+		fmt.Fprint(stdout, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(stdout)
+			break
+		}
+		err := r.exec(scanner.Text())
+		if errors.Is(err, errQuit) {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(stdout, "error: %v\n", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stderr, "btree-repl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+type repl struct {
+	tree     *btree.BTree[string, string]
+	degree   int
+	narrator *narrator
+	out      io.Writer
+	// The inserts and deletes made so far, the most recent last. Reinserting or
+	// deleting again would restore the items, but not the shape, so undo replays
+	// all but the last one on an empty tree instead
+	history []change
+}
+
+// An insert of k with value v, or a delete of k
+type change struct {
+	k, v    string
+	deleted bool
+}
+
+func newREPL(degree int, out io.Writer) *repl {
+	r := &repl{
+		tree:     btree.NewBtree[string, string](degree),
+		degree:   degree,
+		narrator: &narrator{out: out},
+		out:      out,
+	}
+	r.tree.SetTracer(r.narrator)
+	return r
+}
+
+/*
+Executes a single command line. Blank lines are ignored. Returns errQuit if the
+REPL should stop
+*/
+func (r *repl) exec(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	command, args := fields[0], fields[1:]
+
+	switch command {
+	case "insert":
+		if len(args) < 2 {
+			return errors.New("usage: insert KEY VALUE")
+		}
+		r.insert(args[0], strings.Join(args[1:], " "))
+	case "delete":
+		if len(args) != 1 {
+			return errors.New("usage: delete KEY")
+		}
+		r.delete(args[0])
+	case "get":
+		if len(args) != 1 {
+			return errors.New("usage: get KEY")
+		}
+		if v, found := r.tree.Get(args[0]); found {
+			fmt.Fprintf(r.out, "%s = %s\n", args[0], v)
+		} else {
+			fmt.Fprintf(r.out, "%s not found\n", args[0])
+		}
+	case "range":
+		if len(args) > 2 {
+			return errors.New("usage: range [FROM [TO]]")
+		}
+		r.printRange(args)
+	case "undo":
+		if len(args) != 0 {
+			return errors.New("usage: undo")
+		}
+		if len(r.history) == 0 {
+			return errors.New("nothing to undo")
+		}
+		r.narrator.say("undo")
+		r.undo()
+	case "show":
+	case "verbose":
+		if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+			return errors.New("usage: verbose on|off")
+		}
+		r.narrator.enabled = args[0] == "on"
+		return nil
+	case "help":
+		fmt.Fprint(r.out, help)
+		return nil
+	case "quit", "exit":
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command %q, type help for a list of commands", command)
+	}
+
+	r.show()
+	return nil
+}
+
+func (r *repl) insert(k, v string) {
+	r.history = append(r.history, change{k: k, v: v})
+	if old, replaced := r.tree.Replace(k, v); replaced {
+		fmt.Fprintf(r.out, "replaced %s = %s\n", k, old)
+	}
+}
+
+func (r *repl) delete(k string) {
+	if _, found := r.tree.Get(k); !found {
+		fmt.Fprintf(r.out, "%s not found\n", k)
+		return
+	}
+	r.history = append(r.history, change{k: k, deleted: true})
+	r.tree.Delete(k)
+}
+
+/*
+Reverts the last change by replaying the ones before it on an empty tree. The
+replay is not narrated
+*/
+func (r *repl) undo() {
+	r.history = r.history[:len(r.history)-1]
+	r.tree = btree.NewBtree[string, string](r.degree)
+	for _, c := range r.history {
+		if c.deleted {
+			r.tree.Delete(c.k)
+		} else {
+			r.tree.Insert(c.k, c.v)
+		}
+	}
+	r.tree.SetTracer(r.narrator)
+}
+
+func (r *repl) printRange(args []string) {
+	var from string
+	if len(args) > 0 {
+		from = args[0]
+	}
+	items := r.tree.AscendFrom(from)
+	if len(args) == 2 {
+		items = r.tree.Range(from, args[1])
+	}
+	for k, v := range items {
+		fmt.Fprintf(r.out, "%s = %s\n", k, v)
+	}
+}
+
+func (r *repl) show() {
+	if r.tree.Len() == 0 {
+		fmt.Fprintln(r.out, "(empty tree)")
+		return
+	}
+	fmt.Fprint(r.out, r.tree.String())
+}
+
+/*
+narrator is a btree.Tracer which describes structural changes in plain words
+*/
+type narrator struct {
+	out     io.Writer
+	enabled bool
+}
+
+func (n *narrator) say(format string, args ...any) {
+	if n.enabled {
+		fmt.Fprintf(n.out, "  * "+format+"\n", args...)
+	}
+}
+
+func (n *narrator) Split(promoted string, left []string, right []string) {
+	n.say("split a full node into %v and %v, promoting %s to the parent", left, right, promoted)
+}
+
+func (n *narrator) Merge(separator string, merged []string) {
+	n.say("merged two siblings around %s, which moved down from the parent: %v", separator, merged)
+}
+
+func (n *narrator) StealFromLeft(separator string, child []string, sibling []string) {
+	n.say("rotated an item from the left sibling through the parent: child %v, sibling %v, new separator %s", child, sibling, separator)
+}
+
+func (n *narrator) StealFromRight(separator string, child []string, sibling []string) {
+	n.say("rotated an item from the right sibling through the parent: child %v, sibling %v, new separator %s", child, sibling, separator)
+}
+
+func (n *narrator) Rebalance(parent []string, child int) {
+	n.say("child %d of %v has too few items and must be refilled", child, parent)
+}
+
+func (n *narrator) GrowRoot(root []string) {
+	n.say("the root was split, so the tree grew a level. New root %v", root)
+}
+
+func (n *narrator) ShrinkRoot(root []string) {
+	n.say("the root ran out of items, so the tree lost a level. New root %v", root)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func runREPL(t *testing.T, input string, args ...string) string {
+	t.Helper()
+	var stdout, stderr strings.Builder
+	if status := run(args, strings.NewReader(input), &stdout, &stderr); status != 0 {
+		t.Fatalf("run exited with %v: %v", status, stderr.String())
+	}
+	return stdout.String()
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "insert and show",
+			input:    "insert a 1\ninsert b 2\nshow\n",
+			contains: []string{"Node: a:1 b:2 \n"},
+		},
+		{
+			name:     "get",
+			input:    "insert a hello world\nget a\nget b\n",
+			contains: []string{"a = hello world\n", "b not found\n"},
+		},
+		{
+			name:     "range",
+			input:    "insert a 1\ninsert b 2\ninsert c 3\ninsert d 4\nrange b d\n",
+			contains: []string{"b = 2\nc = 3\nNode:"},
+			excludes: []string{"d = 4\n"},
+		},
+		{
+			name:     "delete",
+			input:    "insert a 1\ndelete a\ndelete a\n",
+			contains: []string{"(empty tree)", "a not found"},
+		},
+		{
+			name:     "undo insert",
+			input:    "insert a 1\nundo\nget a\n",
+			contains: []string{"a not found"},
+		},
+		{
+			name:     "undo replace",
+			input:    "insert a 1\ninsert a 2\nundo\nget a\n",
+			contains: []string{"replaced a = 1", "a = 1\n"},
+		},
+		{
+			name:     "undo delete",
+			input:    "insert a 1\ndelete a\nundo\nget a\n",
+			contains: []string{"a = 1\n"},
+		},
+		{
+			name:     "nothing to undo",
+			input:    "undo\n",
+			contains: []string{"error: nothing to undo"},
+		},
+		{
+			name:     "errors",
+			input:    "frobnicate\ninsert a\nverbose maybe\n",
+			contains: []string{`unknown command "frobnicate"`, "usage: insert KEY VALUE", "usage: verbose on|off"},
+		},
+		{
+			name:     "quit",
+			input:    "quit\ninsert a 1\n",
+			excludes: []string{"Node:"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := runREPL(t, test.input)
+			for _, expected := range test.contains {
+				if !strings.Contains(out, expected) {
+					t.Errorf("output\n%v\nexpected to contain %q", out, expected)
+				}
+			}
+			for _, unexpected := range test.excludes {
+				if strings.Contains(out, unexpected) {
+					t.Errorf("output\n%v\nexpected not to contain %q", out, unexpected)
+				}
+			}
+		})
+	}
+}
+
+func TestUndoRestoresShape(t *testing.T) {
+	r := newREPL(2, io.Discard)
+	for _, line := range []string{"insert a 1", "insert b 2", "insert c 3", "insert d 4", "insert e 5", "delete d"} {
+		if err := r.exec(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Deleting b merges nodes, and inserting it again would split them differently
+	before := r.tree.String()
+	for _, line := range []string{"delete b", "undo"} {
+		if err := r.exec(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if after := r.tree.String(); after != before {
+		t.Errorf("tree after undo =\n%v\nexpected\n%v", after, before)
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	input := "insert a 1\ninsert b 2\ninsert c 3\ninsert d 4\ndelete a\ndelete b\nundo\n"
+
+	quiet := runREPL(t, input)
+	if strings.Contains(quiet, "  * ") {
+		t.Errorf("non-verbose output narrated events:\n%v", quiet)
+	}
+
+	verbose := runREPL(t, input, "-verbose")
+	for _, expected := range []string{
+		"split a full node into [a] and [c], promoting b to the parent",
+		"the root was split, so the tree grew a level. New root [b]",
+		"child 0 of [b] has too few items",
+		"rotated an item from the right sibling",
+		"merged two siblings",
+		"the root ran out of items",
+		"  * undo",
+	} {
+		if !strings.Contains(verbose, expected) {
+			t.Errorf("verbose output\n%v\nexpected to contain %q", verbose, expected)
+		}
+	}
+}
+
+func TestInvalidDegree(t *testing.T) {
+	var stdout, stderr strings.Builder
+	if status := run([]string{"-degree", "1"}, strings.NewReader(""), &stdout, &stderr); status != 2 {
+		t.Errorf("run with degree 1 exited with %v; expected 2", status)
+	}
+}
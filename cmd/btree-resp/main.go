@@ -0,0 +1,133 @@
+// Command btree-resp serves a btree over the Redis RESP2 protocol, for use as a
+// local ordered key-value store with redis-cli and Redis client libraries. See
+// package respkv for the supported commands.
+//
+// Usage:
+//
+//	btree-resp [-addr ADDR] [-data FILE] [-degree N]
+//
+// With -data the tree is loaded from the snapshot file at startup and written
+// back to it on shutdown.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/push-and-pray/btree"
+	"github.com/push-and-pray/btree/respkv"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:6380", "address to listen on")
+	data := flag.String("data", "", "snapshot file to load from and save to. Nothing is persisted when empty")
+	degree := flag.Int("degree", 0, "degree of a new tree, picked automatically when 0")
+	flag.Parse()
+
+	if err := serve(*addr, *data, *degree); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serve(addr, data string, degree int) error {
+	tree, err := openTree(data, degree)
+	if err != nil {
+		return err
+	}
+	server := respkv.NewServer(tree)
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("serving %d items on %s", tree.Len(), l.Addr())
+		errs <- server.Serve(l)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	server.Close()
+	<-errs
+	if data == "" {
+		return nil
+	}
+	return saveSnapshot(server, data)
+}
+
+/*
+Loads the tree from the snapshot file at path. Returns a new tree if path is empty
+or does not exist yet
+*/
+func openTree(path string, degree int) (*btree.BTree[string, []byte], error) {
+	var opts []btree.Option
+	if degree != 0 {
+		opts = append(opts, btree.WithDegree(degree))
+	}
+	if path == "" {
+		return btree.New[string, []byte](opts...)
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return btree.New[string, []byte](opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tree, err := btree.ReadSnapshot[string, []byte](bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", path, err)
+	}
+	return tree, nil
+}
+
+/*
+Writes a snapshot of the served tree to path. The snapshot is written to a temporary
+file first and renamed into place, so a crash never leaves a partial snapshot
+*/
+func saveSnapshot(server *respkv.Server, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := server.WriteSnapshot(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
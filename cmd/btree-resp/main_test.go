@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/push-and-pray/btree/respkv"
+)
+
+func TestPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.btree")
+
+	tree, err := openTree(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Insert("a", []byte{0, 1, 2})
+	tree.Insert("b", []byte("text"))
+	if err := saveSnapshot(respkv.NewServer(tree), path); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("saveSnapshot() left %v files; expected only the snapshot", len(entries))
+	}
+
+	restored, err := openTree(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, found := restored.Get("a"); restored.Len() != 2 || !found || !bytes.Equal(v, []byte{0, 1, 2}) {
+		t.Errorf("restored %v items with a = (%v, %v); expected 2 items with a = [0 1 2]", restored.Len(), v, found)
+	}
+}
+
+func TestOpenTree(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.btree")
+	if err := os.WriteFile(corrupt, []byte("not a snapshot"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		degree  int
+		wantErr bool
+	}{
+		{"no file", "", 0, false},
+		{"missing file", filepath.Join(dir, "missing.btree"), 4, false},
+		{"corrupt file", corrupt, 0, true},
+		{"invalid degree", "", 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree, err := openTree(test.path, test.degree)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("openTree() error = %v; expected error %v", err, test.wantErr)
+			}
+			if err == nil && tree.Len() != 0 {
+				t.Errorf("openTree() returned %v items; expected an empty tree", tree.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Command btree-server serves a btree of string keys and values over HTTP/JSON.
+// See package httpkv for the endpoints.
+//
+// Usage:
+//
+//	btree-server [-addr ADDR] [-data FILE] [-degree N] [-save-interval DURATION]
+//
+// With -data the tree is loaded from the snapshot file at startup, and written
+// back to it periodically and on shutdown.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/push-and-pray/btree"
+	"github.com/push-and-pray/btree/httpkv"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	data := flag.String("data", "", "snapshot file to load from and save to. Nothing is persisted when empty")
+	degree := flag.Int("degree", 0, "degree of a new tree, picked automatically when 0")
+	saveInterval := flag.Duration("save-interval", time.Minute, "how often to save the snapshot file, 0 to only save on shutdown")
+	flag.Parse()
+
+	if err := serve(*addr, *data, *degree, *saveInterval); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serve(addr, data string, degree int, saveInterval time.Duration) error {
+	tree, err := openTree(data, degree)
+	if err != nil {
+		return err
+	}
+	kv := httpkv.NewServer(tree)
+	server := &http.Server{Addr: addr, Handler: kv, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if data != "" && saveInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(saveInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := saveSnapshot(kv, data); err != nil {
+						log.Printf("saving snapshot: %v", err)
+					}
+				}
+			}
+		}()
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("serving %d items on %s", tree.Len(), addr)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if data == "" {
+		return nil
+	}
+	return saveSnapshot(kv, data)
+}
+
+/*
+Loads the tree from the snapshot file at path. Returns a new tree if path is empty
+or does not exist yet
+*/
+func openTree(path string, degree int) (*btree.BTree[string, string], error) {
+	var opts []btree.Option
+	if degree != 0 {
+		opts = append(opts, btree.WithDegree(degree))
+	}
+	if path == "" {
+		return btree.New[string, string](opts...)
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return btree.New[string, string](opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	tree, err := btree.ReadSnapshot[string, string](bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", path, err)
+	}
+	return tree, nil
+}
+
+/*
+Writes a snapshot of the served tree to path. The snapshot is written to a temporary
+file first and renamed into place, so a crash never leaves a partial snapshot
+*/
+func saveSnapshot(kv *httpkv.Server, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := kv.WriteSnapshot(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
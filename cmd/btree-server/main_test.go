@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/push-and-pray/btree/httpkv"
+)
+
+func TestPersistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tree.btree")
+
+	tree, err := openTree(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := httpkv.NewServer(tree)
+	ts := httptest.NewServer(kv)
+	c := httpkv.NewClient(ts.URL, ts.Client())
+	for _, k := range []string{"a", "b", "c"} {
+		if _, err := c.Put(ctx, k, k+k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts.Close()
+
+	if err := saveSnapshot(kv, path); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("saveSnapshot() left %v files; expected only the snapshot", len(entries))
+	}
+
+	restored, err := openTree(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, found := restored.Get("b"); restored.Len() != 3 || !found || v != "bb" {
+		t.Errorf("restored %v items with b = (%v, %v); expected 3 items with b = bb", restored.Len(), v, found)
+	}
+}
+
+func TestOpenTree(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.btree")
+	if err := os.WriteFile(corrupt, []byte("not a snapshot"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		degree  int
+		wantErr bool
+	}{
+		{"no file", "", 0, false},
+		{"missing file", filepath.Join(dir, "missing.btree"), 4, false},
+		{"corrupt file", corrupt, 0, true},
+		{"invalid degree", "", 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tree, err := openTree(test.path, test.degree)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("openTree() error = %v; expected error %v", err, test.wantErr)
+			}
+			if err == nil && tree.Len() != 0 {
+				t.Errorf("openTree() returned %v items; expected an empty tree", tree.Len())
+			}
+		})
+	}
+}
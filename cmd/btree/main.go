@@ -0,0 +1,430 @@
+// Command btree builds, inspects and queries btree snapshot files.
+//
+// Usage:
+//
+//	btree load [-o FILE] [-degree N] [-format csv|jsonl] [-header] INPUT
+//	btree get FILE KEY...
+//	btree range [-from KEY] [-to KEY] [-limit N] FILE
+//	btree stats FILE
+//	btree validate FILE
+//	btree dump [-format text|dot|mermaid|json] FILE
+//	btree diff OLD NEW
+//
+// Snapshot files hold a btree of string keys and string values, as written by
+// BTree.WriteSnapshot.
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/push-and-pray/btree"
+)
+
+const usage = `usage: btree <command> [flags] [args]
+
+commands:
+  load      build a snapshot file from CSV or JSON lines
+  get       print the values of keys
+  range     print the items in a key range
+  stats     print statistics about the tree
+  validate  check the invariants of the tree
+  dump      print the structure of the tree
+  diff      print the differences between two trees
+
+Run 'btree <command> -h' for the flags of a command.
+`
+
+// errUsage signals a usage error which has already been reported
+var errUsage = errors.New("usage error")
+
+type tree = btree.BTree[string, string]
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+/*
+Runs the command line given by args. Returns the exit status: 0 on success, 1 on
+errors and 2 on usage errors
+*/
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	commands := map[string]func(args []string, stdin io.Reader, stdout, stderr io.Writer) error{
+		"load":     load,
+		"get":      get,
+		"range":    rangeCmd,
+		"stats":    stats,
+		"validate": validate,
+		"dump":     dump,
+		"diff":     diff,
+	}
+	command, found := commands[args[0]]
+	if !found {
+		fmt.Fprintf(stderr, "btree: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	err := command(args[1:], stdin, stdout, stderr)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		return 2
+	default:
+		fmt.Fprintf(stderr, "btree %s: %v\n", args[0], err)
+		return 1
+	}
+}
+
+/*
+Creates a flag set for a subcommand which reports errors to stderr instead of exiting
+*/
+func newFlagSet(name, positional string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: btree %s [flags] %s\n", name, positional)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+/*
+Parses args and checks the number of positional arguments is within [minArgs, maxArgs].
+A negative maxArgs means no upper limit
+*/
+func parseArgs(fs *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < minArgs || (maxArgs >= 0 && fs.NArg() > maxArgs) {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func readTree(path string) (*tree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return btree.ReadSnapshot[string, string](bufio.NewReader(f))
+}
+
+func load(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("load", "INPUT", stderr)
+	out := fs.String("o", "tree.btree", "snapshot file to write")
+	degree := fs.Int("degree", 0, "degree of the tree, picked automatically when 0")
+	format := fs.String("format", "", "input format, csv or jsonl. Guessed from the file extension when empty")
+	header := fs.Bool("header", false, "skip the first row of CSV input")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	input := fs.Arg(0)
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(input)) {
+		case ".csv":
+			*format = "csv"
+		case ".jsonl", ".ndjson":
+			*format = "jsonl"
+		default:
+			return fmt.Errorf("cannot guess the format of %q, use -format", input)
+		}
+	}
+
+	var opts []btree.Option
+	if *degree != 0 {
+		opts = append(opts, btree.WithDegree(*degree))
+	}
+	t, err := btree.New[string, string](opts...)
+	if err != nil {
+		return err
+	}
+
+	r := stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	switch *format {
+	case "csv":
+		err = loadCSV(t, r, *header)
+	case "jsonl":
+		err = loadJSONL(t, r)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := t.WriteSnapshot(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "loaded %d items into %s\n", t.Len(), *out)
+	return nil
+}
+
+/*
+Inserts key,value rows. Later rows replace earlier rows with the same key
+*/
+func loadCSV(t *tree, r io.Reader, header bool) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header && row == 1 {
+			continue
+		}
+		t.Insert(record[0], record[1])
+	}
+}
+
+/*
+Inserts {"key": ..., "value": ...} lines. String values are stored as is, other
+JSON values as their JSON text
+*/
+func loadJSONL(t *tree, r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var record struct {
+			Key   *string         `json:"key"`
+			Value json.RawMessage `json:"value"`
+		}
+		err := dec.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %w", line, err)
+		}
+		if record.Key == nil {
+			return fmt.Errorf("record %d: missing key", line)
+		}
+
+		var value string
+		if err := json.Unmarshal(record.Value, &value); err != nil {
+			value = string(record.Value)
+		}
+		t.Insert(*record.Key, value)
+	}
+}
+
+func get(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("get", "FILE KEY...", stderr)
+	if err := parseArgs(fs, args, 2, -1); err != nil {
+		return err
+	}
+	t, err := readTree(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	missing := 0
+	for _, k := range fs.Args()[1:] {
+		v, found := t.Get(k)
+		if !found {
+			fmt.Fprintf(stderr, "btree get: key %q not found\n", k)
+			missing++
+			continue
+		}
+		fmt.Fprintln(stdout, v)
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d of %d keys not found", missing, fs.NArg()-1)
+	}
+	return nil
+}
+
+func rangeCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("range", "FILE", stderr)
+	from := fs.String("from", "", "first key of the range, inclusive")
+	to := fs.String("to", "", "last key of the range, exclusive. Unbounded when empty")
+	limit := fs.Int("limit", 0, "maximum number of items to print, 0 for no limit")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+	t, err := readTree(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	items := t.AscendFrom(*from)
+	if *to != "" {
+		items = t.Range(*from, *to)
+	}
+	printed := 0
+	for k, v := range items {
+		if *limit > 0 && printed == *limit {
+			break
+		}
+		fmt.Fprintf(stdout, "%s\t%s\n", k, v)
+		printed++
+	}
+	return nil
+}
+
+func stats(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("stats", "FILE", stderr)
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+	t, err := readTree(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	s := t.Stats()
+	fmt.Fprintf(stdout, "items:        %d\n", s.Items)
+	fmt.Fprintf(stdout, "height:       %d\n", s.Height)
+	fmt.Fprintf(stdout, "nodes:        %d\n", s.Nodes)
+	fmt.Fprintf(stdout, "leaves:       %d\n", s.Leaves)
+	fmt.Fprintf(stdout, "fill factor:  %.3f\n", s.FillFactor)
+	fmt.Fprintf(stdout, "memory bytes: %d\n", s.MemoryBytes)
+	for depth, level := range s.Levels {
+		fmt.Fprintf(stdout, "level %d:      %d nodes, %d items, fill factor %.3f, fill histogram", depth, level.Nodes, level.Items, level.FillFactor)
+		for _, count := range level.FillHistogram {
+			fmt.Fprintf(stdout, " %d", count)
+		}
+		fmt.Fprintln(stdout)
+	}
+	fmt.Fprintf(stdout, "fill histogram:")
+	for _, count := range s.FillHistogram {
+		fmt.Fprintf(stdout, " %d", count)
+	}
+	fmt.Fprintln(stdout)
+	return nil
+}
+
+func validate(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("validate", "FILE", stderr)
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+	t, err := readTree(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err := t.Validate(); err != nil {
+		var verr *btree.ValidationError[string]
+		if errors.As(err, &verr) {
+			for _, v := range verr.Violations {
+				fmt.Fprintln(stdout, v)
+			}
+		}
+		return err
+	}
+	fmt.Fprintf(stdout, "ok: %d items\n", t.Len())
+	return nil
+}
+
+func dump(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("dump", "FILE", stderr)
+	format := fs.String("format", "text", "output format: text, dot, mermaid or json")
+	values := fs.Bool("values", false, "include values in dot and mermaid output")
+	if err := parseArgs(fs, args, 1, 1); err != nil {
+		return err
+	}
+	t, err := readTree(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	opts := btree.ExportOptions[string]{Values: *values, MaxValueLen: 32}
+	switch *format {
+	case "text":
+		_, err = fmt.Fprint(stdout, t.String())
+	case "dot":
+		err = t.WriteDOT(stdout, opts)
+	case "mermaid":
+		err = t.WriteMermaid(stdout, opts)
+	case "json":
+		err = t.WriteJSON(stdout)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	return err
+}
+
+func diff(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("diff", "OLD NEW", stderr)
+	if err := parseArgs(fs, args, 2, 2); err != nil {
+		return err
+	}
+	oldTree, err := readTree(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	newTree, err := readTree(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	nextOld, stopOld := iter.Pull2(oldTree.All())
+	defer stopOld()
+	nextNew, stopNew := iter.Pull2(newTree.All())
+	defer stopNew()
+
+	ok, ov, okOld := nextOld()
+	nk, nv, okNew := nextNew()
+	for okOld || okNew {
+		switch {
+		case !okNew || (okOld && ok < nk):
+			fmt.Fprintf(stdout, "-\t%s\t%s\n", ok, ov)
+			ok, ov, okOld = nextOld()
+		case !okOld || nk < ok:
+			fmt.Fprintf(stdout, "+\t%s\t%s\n", nk, nv)
+			nk, nv, okNew = nextNew()
+		default:
+			if ov != nv {
+				fmt.Fprintf(stdout, "~\t%s\t%s\t%s\n", ok, ov, nv)
+			}
+			ok, ov, okOld = nextOld()
+			nk, nv, okNew = nextNew()
+		}
+	}
+	return nil
+}
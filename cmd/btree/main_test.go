@@ -0,0 +1,174 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+/*
+Runs the command line and returns its exit status, stdout and stderr
+*/
+func runCmd(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr strings.Builder
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+/*
+Loads the given CSV into a snapshot file inside a temporary directory and returns its path
+*/
+func loadTree(t *testing.T, csv string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tree.btree")
+	if status, _, stderr := runCmd(t, csv, "load", "-o", path, "-degree", "2", "-format", "csv", "-"); status != 0 {
+		t.Fatalf("load exited with %v: %v", status, stderr)
+	}
+	return path
+}
+
+func TestLoadFormats(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		args    []string
+	}{
+		{"csv", "in.csv", "a,1\nb,2\nc,3\n", nil},
+		{"csv header", "in.csv", "key,value\na,1\nb,2\nc,3\n", []string{"-header"}},
+		{"jsonl", "in.jsonl", `{"key":"a","value":"1"}` + "\n" + `{"key":"b","value":2}` + "\n" + `{"key":"c","value":"3"}` + "\n", nil},
+		{"duplicates", "in.csv", "a,0\nb,2\nc,3\na,1\n", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := filepath.Join(dir, test.file)
+			if err := os.WriteFile(input, []byte(test.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			out := filepath.Join(dir, "out.btree")
+
+			args := append([]string{"load", "-o", out}, test.args...)
+			status, stdout, stderr := runCmd(t, "", append(args, input)...)
+			if status != 0 {
+				t.Fatalf("load exited with %v: %v", status, stderr)
+			}
+			if !strings.Contains(stdout, "loaded 3 items") {
+				t.Errorf("load printed %q; expected it to report 3 items", stdout)
+			}
+
+			status, stdout, _ = runCmd(t, "", "get", out, "a", "b", "c")
+			if status != 0 || stdout != "1\n2\n3\n" {
+				t.Errorf("get = (%v, %q); expected (0, %q)", status, stdout, "1\n2\n3\n")
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.btree")
+	tests := []struct {
+		name   string
+		stdin  string
+		args   []string
+		status int
+	}{
+		{"missing input", "", []string{"load", "-o", out}, 2},
+		{"unknown extension", "", []string{"load", "-o", out, "in.txt"}, 1},
+		{"missing file", "", []string{"load", "-o", out, filepath.Join(dir, "missing.csv")}, 1},
+		{"bad csv", "a,1,extra\n", []string{"load", "-o", out, "-format", "csv", "-"}, 1},
+		{"bad jsonl", "{not json}\n", []string{"load", "-o", out, "-format", "jsonl", "-"}, 1},
+		{"missing key", `{"value":"1"}` + "\n", []string{"load", "-o", out, "-format", "jsonl", "-"}, 1},
+		{"bad degree", "a,1\n", []string{"load", "-o", out, "-degree", "1", "-format", "csv", "-"}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if status, _, _ := runCmd(t, test.stdin, test.args...); status != test.status {
+				t.Errorf("%v exited with %v; expected %v", test.args, status, test.status)
+			}
+		})
+	}
+}
+
+func TestQueries(t *testing.T) {
+	path := loadTree(t, "a,1\nb,2\nc,3\nd,4\ne,5\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		status   int
+		expected string
+	}{
+		{"get", []string{"get", path, "c"}, 0, "3\n"},
+		{"get missing", []string{"get", path, "a", "z"}, 1, "1\n"},
+		{"range all", []string{"range", path}, 0, "a\t1\nb\t2\nc\t3\nd\t4\ne\t5\n"},
+		{"range bounded", []string{"range", "-from", "b", "-to", "d", path}, 0, "b\t2\nc\t3\n"},
+		{"range limit", []string{"range", "-from", "c", "-limit", "2", path}, 0, "c\t3\nd\t4\n"},
+		{"validate", []string{"validate", path}, 0, "ok: 5 items\n"},
+		{"dump text", []string{"dump", path}, 0, ""},
+		{"missing file", []string{"stats", path + ".missing"}, 1, ""},
+		{"unknown command", []string{"frobnicate"}, 2, ""},
+		{"unknown format", []string{"dump", "-format", "svg", path}, 1, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, stdout, _ := runCmd(t, "", test.args...)
+			if status != test.status {
+				t.Errorf("%v exited with %v; expected %v", test.args, status, test.status)
+			}
+			if test.expected != "" && stdout != test.expected {
+				t.Errorf("%v printed %q; expected %q", test.args, stdout, test.expected)
+			}
+		})
+	}
+}
+
+func TestStatsAndDump(t *testing.T) {
+	path := loadTree(t, "a,1\nb,2\nc,3\nd,4\ne,5\n")
+
+	tests := []struct {
+		args     []string
+		contains []string
+	}{
+		{[]string{"stats", path}, []string{"items:        5", "height:       2", "level 1:"}},
+		{[]string{"dump", "-format", "dot", path}, []string{"digraph btree {", "node0:f0 -> node1;"}},
+		{[]string{"dump", "-format", "mermaid", path}, []string{"flowchart TD"}},
+		{[]string{"dump", "-format", "json", path}, []string{`"keys":["b"]`, `"children":[`}},
+	}
+
+	for _, test := range tests {
+		status, stdout, stderr := runCmd(t, "", test.args...)
+		if status != 0 {
+			t.Fatalf("%v exited with %v: %v", test.args, status, stderr)
+		}
+		for _, expected := range test.contains {
+			if !strings.Contains(stdout, expected) {
+				t.Errorf("%v printed\n%v\nexpected it to contain %q", test.args, stdout, expected)
+			}
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	oldPath := loadTree(t, "a,1\nb,2\nc,3\ne,5\n")
+	newPath := loadTree(t, "b,2\nc,30\nd,4\ne,5\nf,6\n")
+
+	status, stdout, stderr := runCmd(t, "", "diff", oldPath, newPath)
+	if status != 0 {
+		t.Fatalf("diff exited with %v: %v", status, stderr)
+	}
+	expected := "-\ta\t1\n~\tc\t3\t30\n+\td\t4\n+\tf\t6\n"
+	if stdout != expected {
+		t.Errorf("diff printed %q; expected %q", stdout, expected)
+	}
+
+	if _, stdout, _ := runCmd(t, "", "diff", oldPath, oldPath); stdout != "" {
+		t.Errorf("diff of identical trees printed %q", stdout)
+	}
+}
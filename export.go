@@ -0,0 +1,206 @@
+package btree
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ExportOptions controls how WriteDOT and WriteMermaid render a btree
+type ExportOptions[K cmp.Ordered] struct {
+	// Render values next to their keys
+	Values bool
+	// Truncate rendered values to this many characters. Zero means no limit
+	MaxValueLen int
+	// Only render this many levels, starting at the root. Cut off subtrees are
+	// drawn as a single placeholder. Zero means no limit
+	MaxLevels int
+	// Highlight the nodes visited when searching for this key
+	Highlight *K
+}
+
+/*
+Writes the btree as a Graphviz DOT digraph, with each node drawn as a record of its
+key slots and edges leaving from the slots between keys
+*/
+func (t *BTree[K, V]) WriteDOT(w io.Writer, opts ExportOptions[K]) error {
+	ew := &exportWriter{w: w}
+	ew.printf("digraph btree {\n")
+	ew.printf("  node [shape=record, height=0.1];\n")
+
+	t.walkExport(opts, func(id int, n *Node[K, V], onPath bool, parent int, slot int) {
+		if n == nil {
+			ew.printf("  node%d [label=\"...\", shape=plaintext];\n", id)
+		} else {
+			var fields []string
+			for idx, item := range n.items {
+				fields = append(fields, fmt.Sprintf("<f%d> ", idx))
+				fields = append(fields, escapeDOT(t.exportLabel(item, opts)))
+			}
+			fields = append(fields, fmt.Sprintf("<f%d> ", len(n.items)))
+			style := ""
+			if onPath {
+				style = ", style=filled, fillcolor=\"#ffd27f\""
+			}
+			ew.printf("  node%d [label=\"%s\"%s];\n", id, strings.Join(fields, "|"), style)
+		}
+
+		if parent >= 0 {
+			style := ""
+			if onPath {
+				style = " [color=\"#d9480f\", penwidth=2]"
+			}
+			ew.printf("  node%d:f%d -> node%d%s;\n", parent, slot, id, style)
+		}
+	})
+
+	ew.printf("}\n")
+	return ew.err
+}
+
+/*
+Writes the btree as a Mermaid flowchart, with each node drawn as a box of its keys
+*/
+func (t *BTree[K, V]) WriteMermaid(w io.Writer, opts ExportOptions[K]) error {
+	ew := &exportWriter{w: w}
+	ew.printf("flowchart TD\n")
+
+	var highlighted []string
+	t.walkExport(opts, func(id int, n *Node[K, V], onPath bool, parent int, slot int) {
+		if n == nil {
+			ew.printf("  node%d[\"...\"]\n", id)
+		} else {
+			labels := make([]string, len(n.items))
+			for idx, item := range n.items {
+				labels[idx] = escapeMermaid(t.exportLabel(item, opts))
+			}
+			ew.printf("  node%d[\"%s\"]\n", id, strings.Join(labels, " | "))
+		}
+
+		if parent >= 0 {
+			ew.printf("  node%d -->|%d| node%d\n", parent, slot, id)
+		}
+		if onPath {
+			highlighted = append(highlighted, fmt.Sprintf("node%d", id))
+		}
+	})
+
+	if len(highlighted) > 0 {
+		ew.printf("  classDef path fill:#ffd27f,stroke:#d9480f\n")
+		ew.printf("  class %s path\n", strings.Join(highlighted, ","))
+	}
+	return ew.err
+}
+
+type jsonNode[K cmp.Ordered, V any] struct {
+	Keys     []K               `json:"keys"`
+	Values   []V               `json:"values"`
+	Children []*jsonNode[K, V] `json:"children,omitempty"`
+}
+
+/*
+Writes the structure of the btree as JSON. Every node is an object holding its keys,
+values and children. An empty tree is written as null
+*/
+func (t *BTree[K, V]) WriteJSON(w io.Writer) error {
+	var toJSON func(n *Node[K, V]) *jsonNode[K, V]
+	toJSON = func(n *Node[K, V]) *jsonNode[K, V] {
+		node := &jsonNode[K, V]{Keys: n.keys(), Values: make([]V, len(n.items))}
+		for idx, item := range n.items {
+			node.Values[idx] = item.value
+		}
+		for _, child := range n.children {
+			node.Children = append(node.Children, toJSON(child))
+		}
+		return node
+	}
+
+	var root *jsonNode[K, V]
+	if t.root != nil {
+		root = toJSON(t.root)
+	}
+	return json.NewEncoder(w).Encode(root)
+}
+
+/*
+Walks the tree in preorder, calling visit with a unique id for each node, whether
+it is on the highlighted search path, and the id and child slot of its parent. The
+root has parent -1. Subtrees cut off by MaxLevels are visited as a nil node
+*/
+func (t *BTree[K, V]) walkExport(opts ExportOptions[K], visit func(id int, n *Node[K, V], onPath bool, parent int, slot int)) {
+	if t.root == nil {
+		return
+	}
+
+	nextID := 0
+	var walk func(n *Node[K, V], depth int, onPath bool, parent int, slot int)
+	walk = func(n *Node[K, V], depth int, onPath bool, parent int, slot int) {
+		id := nextID
+		nextID++
+		if opts.MaxLevels > 0 && depth >= opts.MaxLevels {
+			visit(id, nil, onPath, parent, slot)
+			return
+		}
+		visit(id, n, onPath, parent, slot)
+
+		// The search path continues into the child the key would be found in,
+		// unless the key is in this node
+		pathChild := -1
+		if onPath {
+			if idx, found := t.find(n.items, *opts.Highlight); !found {
+				pathChild = idx
+			}
+		}
+		for idx, child := range n.children {
+			walk(child, depth+1, idx == pathChild, id, idx)
+		}
+	}
+	walk(t.root, 0, opts.Highlight != nil, -1, 0)
+}
+
+func (t *BTree[K, V]) exportLabel(item Item[K, V], opts ExportOptions[K]) string {
+	if !opts.Values {
+		return fmt.Sprint(item.key)
+	}
+	value := fmt.Sprint(item.value)
+	if runes := []rune(value); opts.MaxValueLen > 0 && len(runes) > opts.MaxValueLen {
+		value = string(runes[:opts.MaxValueLen]) + "…"
+	}
+	return fmt.Sprintf("%v: %v", item.key, value)
+}
+
+// Escapes characters with special meaning in DOT record labels
+func escapeDOT(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', '"', '{', '}', '|', '<', '>':
+			sb.WriteRune('\\')
+		case '\n':
+			sb.WriteString("\\n")
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Escapes characters with special meaning in quoted Mermaid labels
+func escapeMermaid(s string) string {
+	return strings.NewReplacer("\"", "#quot;", "\n", " ").Replace(s)
+}
+
+// exportWriter remembers the first write error, so rendering code does not have to check each write
+type exportWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *exportWriter) printf(format string, args ...any) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
@@ -0,0 +1,150 @@
+package btree
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func exportTestTree() *BTree[int, string] {
+	// Root [20] with leaves [10] and [30 40] at degree 2
+	btree := NewBtree[int, string](2)
+	for _, k := range []int{10, 20, 30, 40} {
+		btree.Insert(k, strings.Repeat("v", k/10))
+	}
+	return btree
+}
+
+func TestWriteDOT(t *testing.T) {
+	var sb strings.Builder
+	if err := exportTestTree().WriteDOT(&sb, ExportOptions[int]{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `digraph btree {
+  node [shape=record, height=0.1];
+  node0 [label="<f0> |20|<f1> "];
+  node1 [label="<f0> |10|<f1> "];
+  node0:f0 -> node1;
+  node2 [label="<f0> |30|<f1> |40|<f2> "];
+  node0:f1 -> node2;
+}
+`
+	if sb.String() != expected {
+		t.Errorf("WriteDOT() =\n%v\nexpected\n%v", sb.String(), expected)
+	}
+}
+
+func TestWriteDOTOptions(t *testing.T) {
+	highlight := 35
+	var sb strings.Builder
+	opts := ExportOptions[int]{Values: true, MaxValueLen: 2, Highlight: &highlight}
+	if err := exportTestTree().WriteDOT(&sb, opts); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+
+	for _, expected := range []string{
+		`node0 [label="<f0> |20: vv|<f1> ", style=filled, fillcolor="#ffd27f"];`,
+		`node1 [label="<f0> |10: v|<f1> "];`,
+		`|40: vv…|`,
+		`node0:f1 -> node2 [color="#d9480f", penwidth=2];`,
+		`node0:f0 -> node1;`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("WriteDOT() =\n%v\nexpected it to contain %v", out, expected)
+		}
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	highlight := 10
+	var sb strings.Builder
+	opts := ExportOptions[int]{Highlight: &highlight}
+	if err := exportTestTree().WriteMermaid(&sb, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `flowchart TD
+  node0["20"]
+  node1["10"]
+  node0 -->|0| node1
+  node2["30 | 40"]
+  node0 -->|1| node2
+  classDef path fill:#ffd27f,stroke:#d9480f
+  class node0,node1 path
+`
+	if sb.String() != expected {
+		t.Errorf("WriteMermaid() =\n%v\nexpected\n%v", sb.String(), expected)
+	}
+}
+
+func TestExportMaxLevels(t *testing.T) {
+	var sb strings.Builder
+	if err := exportTestTree().WriteMermaid(&sb, ExportOptions[int]{MaxLevels: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `flowchart TD
+  node0["20"]
+  node1["..."]
+  node0 -->|0| node1
+  node2["..."]
+  node0 -->|1| node2
+`
+	if sb.String() != expected {
+		t.Errorf("WriteMermaid() =\n%v\nexpected\n%v", sb.String(), expected)
+	}
+}
+
+func TestExportEscaping(t *testing.T) {
+	btree := NewBtree[string, string](2)
+	btree.Insert(`a|b"<c>`, `x"y`)
+
+	var dot, mermaid strings.Builder
+	opts := ExportOptions[string]{Values: true}
+	btree.WriteDOT(&dot, opts)
+	btree.WriteMermaid(&mermaid, opts)
+
+	if !strings.Contains(dot.String(), `a\|b\"\<c\>: x\"y`) {
+		t.Errorf("WriteDOT() did not escape label:\n%v", dot.String())
+	}
+	if !strings.Contains(mermaid.String(), `a|b#quot;<c>: x#quot;y`) {
+		t.Errorf("WriteMermaid() did not escape label:\n%v", mermaid.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestExportWriteError(t *testing.T) {
+	if err := exportTestTree().WriteDOT(failingWriter{}, ExportOptions[int]{}); err == nil {
+		t.Errorf("WriteDOT() did not return the write error")
+	}
+	if err := exportTestTree().WriteMermaid(failingWriter{}, ExportOptions[int]{}); err == nil {
+		t.Errorf("WriteMermaid() did not return the write error")
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var sb strings.Builder
+	if err := exportTestTree().WriteJSON(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"keys":[20],"values":["vv"],"children":[{"keys":[10],"values":["v"]},{"keys":[30,40],"values":["vvv","vvvv"]}]}` + "\n"
+	if sb.String() != expected {
+		t.Errorf("WriteJSON() = %v; expected %v", sb.String(), expected)
+	}
+
+	sb.Reset()
+	if err := NewBtree[int, int](2).WriteJSON(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != "null\n" {
+		t.Errorf("WriteJSON() of empty tree = %v; expected null", sb.String())
+	}
+}
@@ -0,0 +1,92 @@
+package btree
+
+import (
+	"cmp"
+	"sync"
+)
+
+/*
+FreeList keeps nodes dropped by merges and root shrinks, so later splits can reuse
+them instead of allocating. It is safe for concurrent use and may be shared between
+trees with the same key and value types
+*/
+type FreeList[K cmp.Ordered, V any] struct {
+	mu    sync.Mutex
+	nodes []*Node[K, V]
+	size  int
+}
+
+/*
+Creates a free list holding at most size nodes
+*/
+func NewFreeList[K cmp.Ordered, V any](size int) *FreeList[K, V] {
+	if size < 0 {
+		panic("Invalid free list size. Must not be negative")
+	}
+	return &FreeList[K, V]{nodes: make([]*Node[K, V], 0, size), size: size}
+}
+
+/*
+Creates an empty btree which takes nodes from and returns nodes to f
+*/
+func NewBtreeWithFreeList[K cmp.Ordered, V any](degree int, f *FreeList[K, V]) *BTree[K, V] {
+	bt := NewBtree[K, V](degree)
+	bt.freeList = f
+	return bt
+}
+
+/*
+Returns the number of nodes currently held by the free list
+*/
+func (f *FreeList[K, V]) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.nodes)
+}
+
+/*
+Takes a node with room for at least maxItems items and maxChildren children. Returns
+nil if the free list is empty. Nodes too small for the caller are dropped
+*/
+func (f *FreeList[K, V]) get(maxItems, maxChildren int) *Node[K, V] {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.nodes) > 0 {
+		n := f.nodes[len(f.nodes)-1]
+		f.nodes[len(f.nodes)-1] = nil
+		f.nodes = f.nodes[:len(f.nodes)-1]
+		if cap(n.items) >= maxItems && cap(n.children) >= maxChildren {
+			return n
+		}
+	}
+	return nil
+}
+
+/*
+Gives a node back to the free list. Returns false if the list is full
+*/
+func (f *FreeList[K, V]) put(n *Node[K, V]) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.nodes) >= f.size {
+		return false
+	}
+	f.nodes = append(f.nodes, n)
+	return true
+}
+
+/*
+Releases a node which is no longer part of the tree. Its items and children are
+cleared so the free list does not keep keys, values or subtrees alive
+*/
+func (t *BTree[K, V]) freeNode(n *Node[K, V]) {
+	if t.freeList == nil {
+		return
+	}
+	clear(n.items[:cap(n.items)])
+	clear(n.children[:cap(n.children)])
+	n.items = n.items[:0]
+	n.children = n.children[:0]
+	n.invalidateHash()
+	t.freeList.put(n)
+}
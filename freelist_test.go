@@ -0,0 +1,116 @@
+package btree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestFreeListChurn(t *testing.T) {
+	random := rand.New(rand.NewPCG(424242, 1024))
+
+	for d := 2; d < 8; d++ {
+		t.Run(fmt.Sprintf("Churn at degree %v", d), func(t *testing.T) {
+			freeList := NewFreeList[int, int](32)
+			btree := NewBtreeWithFreeList(d, freeList)
+			expected := make(map[int]int)
+
+			for i := range 5000 {
+				key := random.IntN(500)
+				if random.IntN(2) == 0 {
+					btree.Insert(key, i)
+					expected[key] = i
+				} else {
+					btree.Delete(key)
+					delete(expected, key)
+				}
+				if err := btree.Validate(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for k, v := range expected {
+				if got, found := btree.Get(k); !found || got != v {
+					t.Errorf("Get(%d) = (%v, %v); expected (%v, true)", k, got, found, v)
+				}
+			}
+			if btree.Len() != len(expected) {
+				t.Errorf("Len() = %v; expected %v", btree.Len(), len(expected))
+			}
+			if freeList.Len() > 32 {
+				t.Errorf("Free list holds %v nodes; expected at most 32", freeList.Len())
+			}
+		})
+	}
+}
+
+func TestFreeListReusesNodes(t *testing.T) {
+	freeList := NewFreeList[int, int](1024)
+	btree := NewBtreeWithFreeList(2, freeList)
+
+	for i := range 100 {
+		btree.Insert(i, i)
+	}
+	for i := range 100 {
+		btree.Delete(i)
+	}
+	if freeList.Len() == 0 {
+		t.Fatalf("Deleting every item freed no nodes")
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		for i := range 100 {
+			btree.Insert(i, i)
+		}
+		for i := range 100 {
+			btree.Delete(i)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Refilling the tree allocated %v times per run; expected 0", allocs)
+	}
+}
+
+func TestFreeListClearsNodes(t *testing.T) {
+	freeList := NewFreeList[int, *int](8)
+	btree := NewBtreeWithFreeList(2, freeList)
+	for i := range 10 {
+		btree.Insert(i, &i)
+	}
+	for i := range 10 {
+		btree.Delete(i)
+	}
+
+	for _, n := range freeList.nodes {
+		for _, item := range n.items[:cap(n.items)] {
+			if item.value != nil {
+				t.Errorf("Freed node still references a value")
+			}
+		}
+		for _, child := range n.children[:cap(n.children)] {
+			if child != nil {
+				t.Errorf("Freed node still references a child")
+			}
+		}
+	}
+}
+
+func TestFreeListSkipsSmallNodes(t *testing.T) {
+	freeList := NewFreeList[int, int](8)
+	small := NewBtreeWithFreeList(2, freeList)
+	large := NewBtreeWithFreeList(8, freeList)
+
+	for i := range 20 {
+		small.Insert(i, i)
+	}
+	for i := range 20 {
+		small.Delete(i)
+	}
+
+	for i := range 1000 {
+		large.Insert(i, i)
+	}
+	if err := large.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
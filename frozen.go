@@ -0,0 +1,176 @@
+package btree
+
+import (
+	"cmp"
+	"iter"
+)
+
+/*
+FrozenBTree is an immutable snapshot of a btree, packed into two arrays in Eytzinger
+order: the implicit search tree has its root at slot 0 and the children of slot i at
+slots 2i+1 and 2i+2. The tree is complete, so there are no pointers and no partially
+filled nodes, and the top levels of every search share the same few cache lines
+*/
+type FrozenBTree[K cmp.Ordered, V any] struct {
+	keys    []K
+	values  []V
+	compare func(a, b K) int
+}
+
+/*
+Creates a FrozenBTree holding the current items of the btree. Later changes to the
+btree do not affect it
+*/
+func (t *BTree[K, V]) Freeze() *FrozenBTree[K, V] {
+	sortedKeys := make([]K, 0, t.length)
+	sortedValues := make([]V, 0, t.length)
+	for k, v := range t.All() {
+		sortedKeys = append(sortedKeys, k)
+		sortedValues = append(sortedValues, v)
+	}
+
+	f := &FrozenBTree[K, V]{
+		keys:    make([]K, len(sortedKeys)),
+		values:  make([]V, len(sortedValues)),
+		compare: t.compare,
+	}
+	f.layout(sortedKeys, sortedValues, 0, 0)
+	return f
+}
+
+/*
+Places the sorted items into the subtree rooted at slot, by walking it in order.
+Returns the index of the next sorted item to place
+*/
+func (f *FrozenBTree[K, V]) layout(sortedKeys []K, sortedValues []V, next int, slot int) int {
+	if slot >= len(f.keys) {
+		return next
+	}
+	next = f.layout(sortedKeys, sortedValues, next, 2*slot+1)
+	f.keys[slot] = sortedKeys[next]
+	f.values[slot] = sortedValues[next]
+	next++
+	return f.layout(sortedKeys, sortedValues, next, 2*slot+2)
+}
+
+func (f *FrozenBTree[K, V]) cmp(a, b K) int {
+	if f.compare == nil {
+		return cmp.Compare(a, b)
+	}
+	return f.compare(a, b)
+}
+
+/*
+Returns the number of items in the tree
+*/
+func (f *FrozenBTree[K, V]) Len() int {
+	return len(f.keys)
+}
+
+/*
+Attempt to get item with key k. Success is indicated by returned bool
+*/
+func (f *FrozenBTree[K, V]) Get(k K) (V, bool) {
+	for slot := 0; slot < len(f.keys); {
+		c := f.cmp(f.keys[slot], k)
+		if c == 0 {
+			return f.values[slot], true
+		}
+		slot = 2*slot + 1
+		if c < 0 {
+			slot++
+		}
+	}
+	var zeroVal V
+	return zeroVal, false
+}
+
+/*
+Returns the item with the largest key less than or equal to k. Success is indicated by returned bool
+*/
+func (f *FrozenBTree[K, V]) Floor(k K) (K, V, bool) {
+	best := -1
+	for slot := 0; slot < len(f.keys); {
+		c := f.cmp(f.keys[slot], k)
+		if c == 0 {
+			best = slot
+			break
+		}
+		if c < 0 {
+			best = slot
+			slot = 2*slot + 2
+		} else {
+			slot = 2*slot + 1
+		}
+	}
+	return f.item(best)
+}
+
+/*
+Returns the item with the smallest key greater than or equal to k. Success is indicated by returned bool
+*/
+func (f *FrozenBTree[K, V]) Ceiling(k K) (K, V, bool) {
+	best := -1
+	for slot := 0; slot < len(f.keys); {
+		c := f.cmp(f.keys[slot], k)
+		if c == 0 {
+			best = slot
+			break
+		}
+		if c > 0 {
+			best = slot
+			slot = 2*slot + 1
+		} else {
+			slot = 2*slot + 2
+		}
+	}
+	return f.item(best)
+}
+
+func (f *FrozenBTree[K, V]) item(slot int) (K, V, bool) {
+	if slot < 0 {
+		var zeroKey K
+		var zeroVal V
+		return zeroKey, zeroVal, false
+	}
+	return f.keys[slot], f.values[slot], true
+}
+
+/*
+Returns an iterator over all key, value pairs in ascending key order
+*/
+func (f *FrozenBTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if len(f.keys) == 0 {
+			return
+		}
+		for slot := f.leftmost(0); slot >= 0; slot = f.successor(slot) {
+			if !yield(f.keys[slot], f.values[slot]) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the leftmost slot in the subtree rooted at slot
+func (f *FrozenBTree[K, V]) leftmost(slot int) int {
+	for 2*slot+1 < len(f.keys) {
+		slot = 2*slot + 1
+	}
+	return slot
+}
+
+// Returns the slot following slot in key order, or -1 if it is the last
+func (f *FrozenBTree[K, V]) successor(slot int) int {
+	if right := 2*slot + 2; right < len(f.keys) {
+		return f.leftmost(right)
+	}
+	// Climb while slot is a right child, then the parent of the left child is next
+	for slot > 0 && slot%2 == 0 {
+		slot = (slot - 2) / 2
+	}
+	if slot == 0 {
+		return -1
+	}
+	return (slot - 1) / 2
+}
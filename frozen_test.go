@@ -0,0 +1,105 @@
+package btree
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestFreezeSizes(t *testing.T) {
+	// Cover empty, complete and incomplete last levels
+	for size := range 40 {
+		t.Run(fmt.Sprintf("Freeze %v items", size), func(t *testing.T) {
+			btree := NewBtree[int, int](2)
+			for i := range size {
+				btree.Insert(i*10, i)
+			}
+			frozen := btree.Freeze()
+
+			if frozen.Len() != size {
+				t.Errorf("Len() = %v; expected %v", frozen.Len(), size)
+			}
+
+			var keys []int
+			for k, v := range frozen.All() {
+				if v != k/10 {
+					t.Errorf("All() yielded %v:%v; expected value %v", k, v, k/10)
+				}
+				keys = append(keys, k)
+			}
+			if len(keys) != size || !slices.IsSorted(keys) {
+				t.Errorf("All() = %v; expected %v sorted keys", keys, size)
+			}
+
+			for k := -5; k < size*10+5; k++ {
+				v, found := frozen.Get(k)
+				expectedV, expectedFound := btree.Get(k)
+				if found != expectedFound || v != expectedV {
+					t.Errorf("Get(%d) = (%v, %v); expected (%v, %v)", k, v, found, expectedV, expectedFound)
+				}
+
+				fk, _, found := frozen.Floor(k)
+				ek, _, expectedFound := btree.Floor(k)
+				if found != expectedFound || fk != ek {
+					t.Errorf("Floor(%d) = (%v, %v); expected (%v, %v)", k, fk, found, ek, expectedFound)
+				}
+
+				ck, _, found := frozen.Ceiling(k)
+				ek, _, expectedFound = btree.Ceiling(k)
+				if found != expectedFound || ck != ek {
+					t.Errorf("Ceiling(%d) = (%v, %v); expected (%v, %v)", k, ck, found, ek, expectedFound)
+				}
+			}
+		})
+	}
+}
+
+func TestFreezeIsSnapshot(t *testing.T) {
+	btree := NewBtree[string, int](3)
+	btree.Insert("a", 1)
+	frozen := btree.Freeze()
+
+	btree.Insert("a", 2)
+	btree.Insert("b", 3)
+
+	if v, _ := frozen.Get("a"); v != 1 {
+		t.Errorf("Get(a) = %v; expected 1", v)
+	}
+	if _, found := frozen.Get("b"); found {
+		t.Errorf("Frozen tree sees later inserts")
+	}
+}
+
+func TestFreezeWithComparator(t *testing.T) {
+	descending := func(a, b int) int {
+		return cmp.Compare(b, a)
+	}
+	btree, err := New[int, int](WithDegree(2), WithComparator(descending))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 20 {
+		btree.Insert(i, i)
+	}
+	frozen := btree.Freeze()
+
+	if v, found := frozen.Get(7); !found || v != 7 {
+		t.Errorf("Get(7) = (%v, %v); expected (7, true)", v, found)
+	}
+	// In descending order, the floor is the next larger number
+	if k, _, found := frozen.Floor(25); found {
+		t.Errorf("Floor(25) = %v; expected nothing", k)
+	}
+	if k, _, found := frozen.Ceiling(25); !found || k != 19 {
+		t.Errorf("Ceiling(25) = (%v, %v); expected (19, true)", k, found)
+	}
+
+	var keys []int
+	for k := range frozen.All() {
+		keys = append(keys, k)
+	}
+	if !slices.IsSortedFunc(keys, descending) {
+		t.Errorf("All() = %v; expected descending order", keys)
+	}
+}
@@ -0,0 +1,150 @@
+package btree
+
+import (
+	"slices"
+	"testing"
+)
+
+const (
+	fuzzInsert = iota
+	fuzzDelete
+	fuzzGet
+	fuzzFloor
+	fuzzCeiling
+	fuzzOps
+)
+
+// Reference model of the btree: a map for lookups plus a sorted slice of keys for order
+type fuzzModel struct {
+	values map[int]int
+	keys   []int
+}
+
+func (m *fuzzModel) insert(k, v int) {
+	if _, found := m.values[k]; !found {
+		idx, _ := slices.BinarySearch(m.keys, k)
+		m.keys = slices.Insert(m.keys, idx, k)
+	}
+	m.values[k] = v
+}
+
+func (m *fuzzModel) delete(k int) bool {
+	if _, found := m.values[k]; !found {
+		return false
+	}
+	delete(m.values, k)
+	idx, _ := slices.BinarySearch(m.keys, k)
+	m.keys = slices.Delete(m.keys, idx, idx+1)
+	return true
+}
+
+func (m *fuzzModel) floor(k int) (int, bool) {
+	idx, found := slices.BinarySearch(m.keys, k)
+	if found {
+		return k, true
+	}
+	if idx == 0 {
+		return 0, false
+	}
+	return m.keys[idx-1], true
+}
+
+func (m *fuzzModel) ceiling(k int) (int, bool) {
+	idx, _ := slices.BinarySearch(m.keys, k)
+	if idx == len(m.keys) {
+		return 0, false
+	}
+	return m.keys[idx], true
+}
+
+/*
+Decodes data into a degree followed by operations of three bytes each: an opcode and
+a two byte key. Every result is compared against a map and sorted slice model, and the
+tree is validated after every operation
+*/
+func FuzzBTreeOps(f *testing.F) {
+	f.Add([]byte{0})
+	f.Add([]byte{0, fuzzInsert, 0, 1, fuzzInsert, 0, 2, fuzzInsert, 0, 3, fuzzInsert, 0, 4, fuzzDelete, 0, 1, fuzzDelete, 0, 9})
+
+	// Ascending inserts followed by descending deletes at a few degrees
+	for _, degree := range []byte{0, 1, 2, 7, 62} {
+		seed := []byte{degree}
+		for k := range byte(100) {
+			seed = append(seed, fuzzInsert, 0, k)
+		}
+		for k := range byte(100) {
+			seed = append(seed, fuzzDelete, 0, 99-k, fuzzFloor, 0, k, fuzzCeiling, 0, k)
+		}
+		f.Add(seed)
+	}
+
+	// Interleaved inserts and deletes hitting internal nodes
+	interleaved := []byte{0}
+	for k := range byte(120) {
+		interleaved = append(interleaved, fuzzInsert, 0, k*37%128)
+		if k%3 == 2 {
+			interleaved = append(interleaved, fuzzDelete, 0, k*11%128, fuzzGet, 0, k)
+		}
+	}
+	f.Add(interleaved)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		degree := 2 + int(data[0])%63
+		btree := NewBtree[int, int](degree)
+		model := fuzzModel{values: make(map[int]int)}
+
+		for step, pos := 0, 1; pos+3 <= len(data); step, pos = step+1, pos+3 {
+			op := int(data[pos]) % fuzzOps
+			k := (int(data[pos+1])<<8 | int(data[pos+2])) % 1024
+
+			switch op {
+			case fuzzInsert:
+				btree.Insert(k, step)
+				model.insert(k, step)
+			case fuzzDelete:
+				if found, expected := btree.Delete(k), model.delete(k); found != expected {
+					t.Fatalf("step %d: Delete(%d) = %v; expected %v", step, k, found, expected)
+				}
+			case fuzzGet:
+				v, found := btree.Get(k)
+				expected, expectedFound := model.values[k]
+				if found != expectedFound || v != expected {
+					t.Fatalf("step %d: Get(%d) = (%v, %v); expected (%v, %v)", step, k, v, found, expected, expectedFound)
+				}
+			case fuzzFloor:
+				got, _, found := btree.Floor(k)
+				expected, expectedFound := model.floor(k)
+				if found != expectedFound || got != expected {
+					t.Fatalf("step %d: Floor(%d) = (%v, %v); expected (%v, %v)", step, k, got, found, expected, expectedFound)
+				}
+			case fuzzCeiling:
+				got, _, found := btree.Ceiling(k)
+				expected, expectedFound := model.ceiling(k)
+				if found != expectedFound || got != expected {
+					t.Fatalf("step %d: Ceiling(%d) = (%v, %v); expected (%v, %v)", step, k, got, found, expected, expectedFound)
+				}
+			}
+
+			if err := btree.Validate(); err != nil {
+				t.Fatalf("step %d: %v", step, err)
+			}
+			if btree.Len() != len(model.keys) {
+				t.Fatalf("step %d: Len() = %v; expected %v", step, btree.Len(), len(model.keys))
+			}
+		}
+
+		var keys []int
+		for k, v := range btree.All() {
+			if v != model.values[k] {
+				t.Fatalf("All() yielded %v:%v; expected value %v", k, v, model.values[k])
+			}
+			keys = append(keys, k)
+		}
+		if !slices.Equal(keys, model.keys) {
+			t.Fatalf("All() = %v; expected %v", keys, model.keys)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package httpkv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned by the client for keys the server does not hold
+var ErrNotFound = errors.New("httpkv: key not found")
+
+// StatusError is an unexpected response from the server
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("httpkv: server responded %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+/*
+Client talks to a Server. It is safe for concurrent use
+*/
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+/*
+Creates a client for the server at baseURL, such as "http://localhost:8080". A nil
+httpClient uses http.DefaultClient
+*/
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: httpClient}
+}
+
+/*
+Returns the value of key, or ErrNotFound
+*/
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	var item Item
+	if err := c.do(ctx, http.MethodGet, keyPath(key), &item, http.StatusOK); err != nil {
+		return "", notFound(err)
+	}
+	return item.Value, nil
+}
+
+/*
+Inserts or replaces the value of key. Returns true if an existing value was replaced
+*/
+func (c *Client) Put(ctx context.Context, key, value string) (bool, error) {
+	body, err := json.Marshal(putRequest{Value: &value})
+	if err != nil {
+		return false, err
+	}
+	var item Item
+	req, err := c.newRequest(ctx, http.MethodPut, keyPath(key), bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	status, err := c.send(req, &item, http.StatusOK, http.StatusCreated)
+	return status == http.StatusOK, err
+}
+
+/*
+Deletes key. Returns ErrNotFound if the server did not hold it
+*/
+func (c *Client) Delete(ctx context.Context, key string) error {
+	return notFound(c.do(ctx, http.MethodDelete, keyPath(key), nil, http.StatusNoContent))
+}
+
+/*
+RangeQuery selects the items in [From, To). An empty To means no upper bound. A
+zero Limit uses the server's default page size. Cursor is the Next field of the
+previous page
+*/
+type RangeQuery struct {
+	From   string
+	To     string
+	Limit  int
+	Cursor string
+}
+
+/*
+Returns one page of the items selected by q
+*/
+func (c *Client) Range(ctx context.Context, q RangeQuery) (Page, error) {
+	params := url.Values{}
+	if q.From != "" {
+		params.Set("from", q.From)
+	}
+	if q.To != "" {
+		params.Set("to", q.To)
+	}
+	if q.Limit != 0 {
+		params.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Cursor != "" {
+		params.Set("cursor", q.Cursor)
+	}
+
+	var page Page
+	err := c.do(ctx, http.MethodGet, "/range?"+params.Encode(), &page, http.StatusOK)
+	return page, err
+}
+
+/*
+Returns an iterator over the items in [from, to), fetching pages of pageSize items
+as needed. Iteration stops after the first error
+*/
+func (c *Client) Scan(ctx context.Context, from, to string, pageSize int) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		q := RangeQuery{From: from, To: to, Limit: pageSize}
+		for {
+			page, err := c.Range(ctx, q)
+			if err != nil {
+				yield(Item{}, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if page.Next == "" {
+				return
+			}
+			q.Cursor = page.Next
+		}
+	}
+}
+
+// Returns statistics about the tree served
+func (c *Client) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+	err := c.do(ctx, http.MethodGet, "/stats", &stats, http.StatusOK)
+	return stats, err
+}
+
+// Translates a 404 response to a key request into ErrNotFound
+func notFound(err error) error {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+func keyPath(key string) string {
+	return "/keys/" + url.PathEscape(key)
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, out any, expected int) error {
+	req, err := c.newRequest(ctx, method, path, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.send(req, out, expected)
+	return err
+}
+
+/*
+Sends req and decodes the response into out, if not nil. Returns the status code,
+and an error if it is not one of expected
+*/
+func (c *Client) send(req *http.Request, out any, expected ...int) (int, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	for _, status := range expected {
+		if resp.StatusCode != status {
+			continue
+		}
+		if out == nil {
+			return status, nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return status, fmt.Errorf("httpkv: decoding response: %w", err)
+		}
+		return status, nil
+	}
+
+	var errResp errorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		errResp.Error = resp.Status
+	}
+	return resp.StatusCode, &StatusError{StatusCode: resp.StatusCode, Message: errResp.Error}
+}
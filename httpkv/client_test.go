@@ -0,0 +1,182 @@
+package httpkv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/push-and-pray/btree"
+)
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	ts := httptest.NewServer(NewServer(btree.NewBtree[string, string](3)))
+	t.Cleanup(ts.Close)
+	return NewClient(ts.URL+"/", ts.Client())
+}
+
+func TestClientKeys(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of missing key = %v; expected ErrNotFound", err)
+	}
+	for _, test := range []struct {
+		key, value string
+		replaced   bool
+	}{
+		{"a", "1", false},
+		{"a", "2", true},
+		{"with space/and slash?", "3", false},
+		{"", "empty", false},
+	} {
+		replaced, err := c.Put(ctx, test.key, test.value)
+		if err != nil || replaced != test.replaced {
+			t.Errorf("Put(%q) = (%v, %v); expected (%v, nil)", test.key, replaced, err, test.replaced)
+		}
+		if got, err := c.Get(ctx, test.key); err != nil || got != test.value {
+			t.Errorf("Get(%q) = (%q, %v); expected (%q, nil)", test.key, got, err, test.value)
+		}
+	}
+
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Errorf("Delete() = %v", err)
+	}
+	if err := c.Delete(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of missing key = %v; expected ErrNotFound", err)
+	}
+}
+
+func TestClientScan(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+	for i := range 50 {
+		if _, err := c.Put(ctx, fmt.Sprintf("k%02d", i), fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		from, to string
+		pageSize int
+		expected int
+	}{
+		{"", "", 0, 50},
+		{"", "", 7, 50},
+		{"k10", "k20", 3, 10},
+		{"k45", "", 5, 5},
+		{"z", "", 5, 0},
+	}
+
+	for _, test := range tests {
+		count := 0
+		prev := ""
+		for item, err := range c.Scan(ctx, test.from, test.to, test.pageSize) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if item.Key <= prev || item.Key < test.from || (test.to != "" && item.Key >= test.to) {
+				t.Errorf("Scan(%q, %q) yielded %v after %v", test.from, test.to, item.Key, prev)
+			}
+			prev = item.Key
+			count++
+		}
+		if count != test.expected {
+			t.Errorf("Scan(%q, %q, %v) yielded %v items; expected %v", test.from, test.to, test.pageSize, count, test.expected)
+		}
+	}
+
+	// Stopping early must not fetch or yield further pages
+	count := 0
+	for range c.Scan(ctx, "", "", 4) {
+		count++
+		if count == 6 {
+			break
+		}
+	}
+	if count != 6 {
+		t.Errorf("Scan() stopped after %v items; expected 6", count)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	_, err := c.Range(ctx, RangeQuery{Limit: MaxLimit + 1})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Range() with too large limit = %v; expected a 400 StatusError", err)
+	}
+
+	for _, err := range c.Scan(ctx, "", "", -1) {
+		if err == nil {
+			t.Errorf("Scan() with negative page size yielded no error")
+		}
+	}
+
+	unreachable := NewClient("http://127.0.0.1:0", nil)
+	if _, err := unreachable.Get(ctx, "a"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() from unreachable server = %v; expected a transport error", err)
+	}
+}
+
+func TestClientStats(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+	for i := range 10 {
+		c.Put(ctx, fmt.Sprint(i), "v")
+	}
+
+	stats, err := c.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Len != 10 || stats.Height < 2 {
+		t.Errorf("Stats() = %+v; expected 10 items over at least 2 levels", stats)
+	}
+}
+
+func TestClientConcurrent(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				key := fmt.Sprintf("%d-%d", w, i)
+				if _, err := c.Put(ctx, key, key); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := c.Get(ctx, key); err != nil {
+					t.Error(err)
+					return
+				}
+				if i%3 == 0 {
+					if err := c.Delete(ctx, key); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats, err := c.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Len != 8*33 {
+		t.Errorf("Stats().Len = %v; expected %v", stats.Len, 8*33)
+	}
+}
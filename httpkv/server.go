@@ -0,0 +1,230 @@
+// Package httpkv serves a btree of string keys and values over HTTP/JSON, and
+// provides a client for it.
+//
+// Endpoints:
+//
+//	GET    /keys/{key}                      the item with the key
+//	PUT    /keys/{key}                      insert or replace the item, body {"value": ...}
+//	DELETE /keys/{key}                      delete the item
+//	GET    /range?from=&to=&limit=&cursor=  the items in [from, to), one page at a time
+//	GET    /stats                           statistics about the tree
+//
+// Errors are reported as {"error": ...} with a matching status code.
+package httpkv
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/push-and-pray/btree"
+)
+
+const (
+	// Page size of /range when no limit is given
+	DefaultLimit = 100
+	// Largest page size of /range
+	MaxLimit = 1000
+	// Largest accepted request body
+	maxBodyBytes = 1 << 20
+)
+
+// Item is a key, value pair
+type Item struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+/*
+Page is one page of a range query. Next is the cursor of the following page, and
+empty on the last page
+*/
+type Page struct {
+	Items []Item `json:"items"`
+	Next  string `json:"next,omitempty"`
+}
+
+// Stats describes the tree served
+type Stats struct {
+	Len         int     `json:"len"`
+	Height      int     `json:"height"`
+	Nodes       int     `json:"nodes"`
+	Leaves      int     `json:"leaves"`
+	FillFactor  float64 `json:"fill_factor"`
+	MemoryBytes int     `json:"memory_bytes"`
+}
+
+type putRequest struct {
+	Value *string `json:"value"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+/*
+Server is an http.Handler serving a btree. It is safe for concurrent use
+*/
+type Server struct {
+	mu   sync.RWMutex
+	tree *btree.BTree[string, string]
+	mux  *http.ServeMux
+}
+
+/*
+Creates a server for tree. The tree must not be used directly while the server is
+in use
+*/
+func NewServer(tree *btree.BTree[string, string]) *Server {
+	s := &Server{tree: tree, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /keys/{key...}", s.handleGet)
+	s.mux.HandleFunc("PUT /keys/{key...}", s.handlePut)
+	s.mux.HandleFunc("DELETE /keys/{key...}", s.handleDelete)
+	s.mux.HandleFunc("GET /range", s.handleRange)
+	s.mux.HandleFunc("GET /stats", s.handleStats)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+/*
+Writes a snapshot of the tree to w, as btree.WriteSnapshot. Writes are blocked
+while the snapshot is taken
+*/
+func (s *Server) WriteSnapshot(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.WriteSnapshot(w)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	s.mu.RLock()
+	value, found := s.tree.Get(key)
+	s.mu.RUnlock()
+
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Errorf("key %q not found", key))
+		return
+	}
+	writeJSON(w, http.StatusOK, Item{Key: key, Value: value})
+}
+
+func (s *Server) handlePut(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	var req putRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decoding body: %w", err))
+		return
+	}
+	if req.Value == nil {
+		writeError(w, http.StatusBadRequest, errors.New(`body is missing "value"`))
+		return
+	}
+
+	s.mu.Lock()
+	_, replaced := s.tree.Replace(key, *req.Value)
+	s.mu.Unlock()
+
+	status := http.StatusCreated
+	if replaced {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, Item{Key: key, Value: *req.Value})
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	s.mu.Lock()
+	_, found := s.tree.Get(key)
+	if found {
+		s.tree.Delete(key)
+	}
+	s.mu.Unlock()
+
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Errorf("key %q not found", key))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+Serves the items in [from, to) after the cursor, if any. An empty to means no upper
+bound. The cursor encodes the first key of the next page, so pages stay consistent
+with concurrent writes to keys which have not been reached yet
+*/
+func (s *Server) handleRange(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to := query.Get("from"), query.Get("to")
+
+	limit := DefaultLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > MaxLimit {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d, got %q", MaxLimit, raw))
+			return
+		}
+		limit = n
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		next, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid cursor %q", cursor))
+			return
+		}
+		from = string(next)
+	}
+
+	s.mu.RLock()
+	items := s.tree.AscendFrom(from)
+	if to != "" {
+		items = s.tree.Range(from, to)
+	}
+	page := Page{Items: []Item{}}
+	for k, v := range items {
+		if len(page.Items) == limit {
+			page.Next = base64.RawURLEncoding.EncodeToString([]byte(k))
+			break
+		}
+		page.Items = append(page.Items, Item{Key: k, Value: v})
+	}
+	s.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	stats := s.tree.Stats()
+	length := s.tree.Len()
+	s.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, Stats{
+		Len:         length,
+		Height:      stats.Height,
+		Nodes:       stats.Nodes,
+		Leaves:      stats.Leaves,
+		FillFactor:  stats.FillFactor,
+		MemoryBytes: stats.MemoryBytes,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,172 @@
+package httpkv
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/push-and-pray/btree"
+)
+
+func newTestServer(keys ...string) *Server {
+	tree := btree.NewBtree[string, string](2)
+	for _, k := range keys {
+		tree.Insert(k, strings.ToUpper(k))
+	}
+	return NewServer(tree)
+}
+
+func serve(s *Server, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestServerKeys(t *testing.T) {
+	s := newTestServer("a", "b")
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+		status int
+		resp   string
+	}{
+		{"GET", "/keys/a", "", http.StatusOK, `{"key":"a","value":"A"}`},
+		{"GET", "/keys/z", "", http.StatusNotFound, `{"error":"key \"z\" not found"}`},
+		{"PUT", "/keys/c", `{"value":"C"}`, http.StatusCreated, `{"key":"c","value":"C"}`},
+		{"PUT", "/keys/c", `{"value":"CC"}`, http.StatusOK, `{"key":"c","value":"CC"}`},
+		{"GET", "/keys/c", "", http.StatusOK, `{"key":"c","value":"CC"}`},
+		{"PUT", "/keys/a%2Fb", `{"value":"slash"}`, http.StatusCreated, `{"key":"a/b","value":"slash"}`},
+		{"GET", "/keys/a%2Fb", "", http.StatusOK, `{"key":"a/b","value":"slash"}`},
+		{"PUT", "/keys/d", `{}`, http.StatusBadRequest, `{"error":"body is missing \"value\""}`},
+		{"PUT", "/keys/d", `not json`, http.StatusBadRequest, ""},
+		{"PUT", "/keys/d", `{"value":"D","extra":1}`, http.StatusBadRequest, ""},
+		{"DELETE", "/keys/a", "", http.StatusNoContent, ""},
+		{"DELETE", "/keys/a", "", http.StatusNotFound, `{"error":"key \"a\" not found"}`},
+		{"GET", "/keys/a", "", http.StatusNotFound, ""},
+		{"POST", "/keys/a", "", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, test := range tests {
+		rec := serve(s, test.method, test.target, test.body)
+		if rec.Code != test.status {
+			t.Errorf("%v %v = %v; expected %v", test.method, test.target, rec.Code, test.status)
+		}
+		if got := strings.TrimSpace(rec.Body.String()); test.resp != "" && got != test.resp {
+			t.Errorf("%v %v body = %v; expected %v", test.method, test.target, got, test.resp)
+		}
+	}
+}
+
+func TestServerRange(t *testing.T) {
+	s := newTestServer("a", "b", "c", "d", "e", "f", "g")
+
+	tests := []struct {
+		query string
+		keys  string
+		next  bool
+	}{
+		{"", "abcdefg", false},
+		{"?from=c", "cdefg", false},
+		{"?from=b&to=e", "bcd", false},
+		{"?to=c", "ab", false},
+		{"?from=bb&to=ee", "cde", false},
+		{"?limit=3", "abc", true},
+		{"?from=c&to=f&limit=3", "cde", false},
+		{"?from=c&to=g&limit=3", "cde", true},
+	}
+
+	for _, test := range tests {
+		rec := serve(s, "GET", "/range"+test.query, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /range%v = %v: %v", test.query, rec.Code, rec.Body.String())
+		}
+		var page Page
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+
+		var keys string
+		for _, item := range page.Items {
+			keys += item.Key
+		}
+		if keys != test.keys || (page.Next != "") != test.next {
+			t.Errorf("GET /range%v = (%v, next %q); expected (%v, next %v)", test.query, keys, page.Next, test.keys, test.next)
+		}
+	}
+}
+
+func TestServerRangeCursor(t *testing.T) {
+	keys := make([]string, 25)
+	for i := range keys {
+		keys[i] = strconv.Itoa(100 + i)
+	}
+	s := newTestServer(keys...)
+
+	var got []string
+	query := "/range?from=105&limit=7"
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("cursor did not terminate")
+		}
+		rec := serve(s, "GET", query, "")
+		var page Page
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range page.Items {
+			got = append(got, item.Key)
+		}
+		if page.Next == "" {
+			break
+		}
+		query = "/range?limit=7&cursor=" + page.Next
+	}
+
+	if strings.Join(got, ",") != strings.Join(keys[5:], ",") {
+		t.Errorf("paginated keys = %v; expected %v", got, keys[5:])
+	}
+}
+
+func TestServerRangeErrors(t *testing.T) {
+	s := newTestServer("a")
+	for _, query := range []string{"?limit=0", "?limit=-1", "?limit=x", "?limit=1001", "?cursor=!!"} {
+		if rec := serve(s, "GET", "/range"+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /range%v = %v; expected %v", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestServerStats(t *testing.T) {
+	s := newTestServer("a", "b", "c", "d")
+	rec := serve(s, "GET", "/stats", "")
+
+	var stats Stats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Len != 4 || stats.Height != 2 || stats.Nodes != 3 || stats.Leaves != 2 {
+		t.Errorf("GET /stats = %+v; expected 4 items in 3 nodes over 2 levels", stats)
+	}
+}
+
+func TestServerSnapshot(t *testing.T) {
+	s := newTestServer("a", "b", "c")
+	var buf bytes.Buffer
+	if err := s.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := btree.ReadSnapshot[string, string](&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, found := restored.Get("b"); restored.Len() != 3 || !found || v != "B" {
+		t.Errorf("restored %v items with b = (%v, %v); expected 3 items with b = B", restored.Len(), v, found)
+	}
+}
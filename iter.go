@@ -0,0 +1,86 @@
+package btree
+
+import (
+	"iter"
+)
+
+/*
+Returns an iterator over all key, value pairs in ascending key order.
+The tree must not be modified while iterating
+*/
+func (t *BTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.root == nil {
+			return
+		}
+		t.ascend(t.root, yield)
+	}
+}
+
+/*
+In-order traversal of the subtree rooted at n. Returns false if yield asked to stop
+*/
+func (t *BTree[K, V]) ascend(n *Node[K, V], yield func(K, V) bool) bool {
+	for idx, item := range n.items {
+		if !n.isLeaf() && !t.ascend(n.children[idx], yield) {
+			return false
+		}
+		if !yield(item.key, item.value) {
+			return false
+		}
+	}
+
+	if n.isLeaf() {
+		return true
+	}
+	return t.ascend(n.children[len(n.children)-1], yield)
+}
+
+/*
+Returns an iterator over all key, value pairs with keys greater than or equal to
+pivot, in ascending key order. The tree must not be modified while iterating
+*/
+func (t *BTree[K, V]) AscendFrom(pivot K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.root == nil {
+			return
+		}
+		t.ascendFrom(t.root, pivot, yield)
+	}
+}
+
+/*
+Returns an iterator over all key, value pairs with keys in the half-open range
+[from, to), in ascending key order. The tree must not be modified while iterating
+*/
+func (t *BTree[K, V]) Range(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range t.AscendFrom(from) {
+			if t.cmp(k, to) >= 0 || !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+/*
+In-order traversal of the items in the subtree rooted at n which are not smaller
+than pivot. Returns false if yield asked to stop
+*/
+func (t *BTree[K, V]) ascendFrom(n *Node[K, V], pivot K, yield func(K, V) bool) bool {
+	idx, found := t.find(n.items, pivot)
+
+	// Only the child left of the first item can hold keys on both sides of pivot
+	if !n.isLeaf() && !found && !t.ascendFrom(n.children[idx], pivot, yield) {
+		return false
+	}
+	for i := idx; i < len(n.items); i++ {
+		if !yield(n.items[i].key, n.items[i].value) {
+			return false
+		}
+		if !n.isLeaf() && !t.ascend(n.children[i+1], yield) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package btree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestBTreeAll(t *testing.T) {
+	r := rand.New(rand.NewPCG(424242, 1024))
+
+	for d := 2; d < 10; d++ {
+		t.Run(fmt.Sprintf("All at degree %v", d), func(t *testing.T) {
+			btree := NewBtree[int, int](d)
+			expected := make(map[int]int)
+			for range 500 {
+				key := r.IntN(1000)
+				btree.Insert(key, key*2)
+				expected[key] = key * 2
+			}
+
+			var keys []int
+			for k, v := range btree.All() {
+				if v != expected[k] {
+					t.Errorf("All yielded %v:%v; expected value %v", k, v, expected[k])
+				}
+				keys = append(keys, k)
+			}
+
+			if len(keys) != len(expected) {
+				t.Errorf("All yielded %v keys; expected %v", len(keys), len(expected))
+			}
+			if !slices.IsSorted(keys) {
+				t.Errorf("All yielded keys out of order: %v", keys)
+			}
+		})
+	}
+}
+
+func TestBTreeAllStop(t *testing.T) {
+	btree := NewBtree[int, int](2)
+	for i := range 100 {
+		btree.Insert(i, i)
+	}
+
+	var keys []int
+	for k := range btree.All() {
+		if k == 10 {
+			break
+		}
+		keys = append(keys, k)
+	}
+
+	if !slices.Equal(keys, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Errorf("All did not stop at break: %v", keys)
+	}
+}
+
+func TestBTreeAllEmpty(t *testing.T) {
+	btree := NewBtree[int, int](3)
+	for k, v := range btree.All() {
+		t.Errorf("Empty tree yielded %v:%v", k, v)
+	}
+}
+
+func TestBTreeAscendFromAndRange(t *testing.T) {
+	for d := 2; d < 6; d++ {
+		btree := NewBtree[int, int](d)
+		for i := 0; i < 200; i += 2 {
+			btree.Insert(i, i)
+		}
+
+		tests := []struct {
+			from, to int
+		}{
+			{-10, 5},
+			{0, 0},
+			{0, 1},
+			{7, 31},
+			{8, 30},
+			{150, 1000},
+			{198, 199},
+			{199, 300},
+		}
+
+		t.Run(fmt.Sprintf("Range at degree %v", d), func(t *testing.T) {
+			for _, test := range tests {
+				var expected []int
+				for i := 0; i < 200; i += 2 {
+					if i >= test.from && i < test.to {
+						expected = append(expected, i)
+					}
+				}
+
+				var keys []int
+				for k := range btree.Range(test.from, test.to) {
+					keys = append(keys, k)
+				}
+				if !slices.Equal(keys, expected) {
+					t.Errorf("Range(%d, %d) = %v; expected %v", test.from, test.to, keys, expected)
+				}
+
+				count := 0
+				for k := range btree.AscendFrom(test.from) {
+					if k < test.from {
+						t.Errorf("AscendFrom(%d) yielded %d", test.from, k)
+					}
+					count++
+				}
+				expectedCount := 0
+				for i := 0; i < 200; i += 2 {
+					if i >= test.from {
+						expectedCount++
+					}
+				}
+				if count != expectedCount {
+					t.Errorf("AscendFrom(%d) yielded %d keys; expected %d", test.from, count, expectedCount)
+				}
+			}
+		})
+	}
+}
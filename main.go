@@ -5,19 +5,29 @@ import (
 )
 
 type BTree[K cmp.Ordered, V any] struct {
-	degree int
-	root   *Node[K, V]
+	degree   int
+	root     *Node[K, V]
+	length   int
+	tracer   Tracer[K]
+	freeList *FreeList[K, V]
+	// Custom key ordering. Nil means the natural ordering of K
+	compare func(a, b K) int
+	// Encoding of items for Merkle hashing. Nil means defaultItemHasher
+	hashItem ItemHasher[K, V]
 }
 
 type Node[K cmp.Ordered, V any] struct {
 	children children[K, V]
 	items    items[K, V]
+	// Cached Merkle hash of the subtree. Nil until RootHash first computes it
+	merkle *merkleHash
 }
 type children[K cmp.Ordered, V any] []*Node[K, V]
 
+// The value is placed first, so that a zero-size V adds no padding to Item
 type Item[K cmp.Ordered, V any] struct {
-	key   K
 	value V
+	key   K
 }
 type items[K cmp.Ordered, V any] []Item[K, V]
 
@@ -50,6 +60,11 @@ func NewBtree[K cmp.Ordered, V any](degree int) *BTree[K, V] {
 }
 
 func (t *BTree[K, V]) newNode() *Node[K, V] {
+	if t.freeList != nil {
+		if n := t.freeList.get(t.maxItems(), t.maxChildren()); n != nil {
+			return n
+		}
+	}
 	return &Node[K, V]{
 		children: make([]*Node[K, V], 0, t.maxChildren()),
 		items:    make([]Item[K, V], 0, t.maxItems()),
@@ -60,27 +75,134 @@ func (t *BTree[K, V]) newNode() *Node[K, V] {
 Attempt to get item with key k. Success is indicated by returned bool
 */
 func (t *BTree[K, V]) Get(k K) (V, bool) {
-	if t.root == nil {
+	item := t.lookup(k)
+	if item == nil {
 		var zeroVal V
 		return zeroVal, false
 	}
-	item, found := t.get(k, t.root)
-	return item.value, found
+	return item.value, true
+}
+
+/*
+Returns a pointer to the item with key k, or nil if it does not exist. The pointer is
+valid until the tree is modified
+*/
+func (t *BTree[K, V]) lookup(k K) *Item[K, V] {
+	if t.root == nil {
+		return nil
+	}
+	return t.get(k, t.root)
+}
+
+/*
+Returns the number of items in the btree
+*/
+func (t *BTree[K, V]) Len() int {
+	return t.length
+}
+
+/*
+Returns the item with the smallest key. Success is indicated by returned bool
+*/
+func (t *BTree[K, V]) Min() (K, V, bool) {
+	if t.root == nil {
+		var zeroKey K
+		var zeroVal V
+		return zeroKey, zeroVal, false
+	}
+	n := t.root
+	for !n.isLeaf() {
+		n = n.children[0]
+	}
+	item := n.items[0]
+	return item.key, item.value, true
+}
+
+/*
+Returns the item with the largest key. Success is indicated by returned bool
+*/
+func (t *BTree[K, V]) Max() (K, V, bool) {
+	if t.root == nil {
+		var zeroKey K
+		var zeroVal V
+		return zeroKey, zeroVal, false
+	}
+	n := t.root
+	for !n.isLeaf() {
+		n = n.children[len(n.children)-1]
+	}
+	item := n.items[len(n.items)-1]
+	return item.key, item.value, true
+}
+
+/*
+Returns the item with the largest key less than or equal to k. Success is indicated by returned bool
+*/
+func (t *BTree[K, V]) Floor(k K) (K, V, bool) {
+	var best *Item[K, V]
+	for n := t.root; n != nil; {
+		idx, found := t.find(n.items, k)
+		if found {
+			best = &n.items[idx]
+			break
+		}
+		if idx > 0 {
+			best = &n.items[idx-1]
+		}
+		if n.isLeaf() {
+			break
+		}
+		n = n.children[idx]
+	}
+
+	if best == nil {
+		var zeroKey K
+		var zeroVal V
+		return zeroKey, zeroVal, false
+	}
+	return best.key, best.value, true
+}
+
+/*
+Returns the item with the smallest key greater than or equal to k. Success is indicated by returned bool
+*/
+func (t *BTree[K, V]) Ceiling(k K) (K, V, bool) {
+	var best *Item[K, V]
+	for n := t.root; n != nil; {
+		idx, found := t.find(n.items, k)
+		if found {
+			best = &n.items[idx]
+			break
+		}
+		if idx < len(n.items) {
+			best = &n.items[idx]
+		}
+		if n.isLeaf() {
+			break
+		}
+		n = n.children[idx]
+	}
+
+	if best == nil {
+		var zeroKey K
+		var zeroVal V
+		return zeroKey, zeroVal, false
+	}
+	return best.key, best.value, true
 }
 
 /*
-Attempt to get item with key k from subtree rooted at n. Success is indicated by returned bool
+Attempt to get item with key k from subtree rooted at n. Returns nil if it does not exist
 */
-func (t *BTree[K, V]) get(k K, n *Node[K, V]) (Item[K, V], bool) {
-	idx, found := n.items.find(k)
+func (t *BTree[K, V]) get(k K, n *Node[K, V]) *Item[K, V] {
+	idx, found := t.find(n.items, k)
 
 	if found {
-		return n.items[idx], true
+		return &n.items[idx]
 	}
 
 	if n.isLeaf() {
-		var zeroVal Item[K, V]
-		return zeroVal, false
+		return nil
 	}
 
 	return t.get(k, n.children[idx])
@@ -92,6 +214,7 @@ Splits a node n. Returns the promoted item and the new node
 */
 func (t *BTree[K, V]) split(n *Node[K, V]) (Item[K, V], *Node[K, V]) {
 	median := len(n.items) / 2
+	n.invalidateHash()
 
 	promotedItem := n.items[median]
 	newNode := t.newNode()
@@ -103,6 +226,9 @@ func (t *BTree[K, V]) split(n *Node[K, V]) (Item[K, V], *Node[K, V]) {
 		n.children = n.children[:median+1]
 	}
 
+	if t.tracer != nil {
+		t.tracer.Split(promotedItem.key, n.keys(), newNode.keys())
+	}
 	return promotedItem, newNode
 }
 
@@ -110,11 +236,22 @@ func (t *BTree[K, V]) split(n *Node[K, V]) (Item[K, V], *Node[K, V]) {
 Insert key,value pair into btree
 */
 func (t *BTree[K, V]) Insert(k K, v V) {
+	item, _ := t.upsert(k)
+	item.value = v
+}
+
+/*
+Find the item with key k, inserting it with a zero value if it does not exist. Returns
+a pointer to the item, which is valid until the tree is modified again, and whether
+the item already existed
+*/
+func (t *BTree[K, V]) upsert(k K) (*Item[K, V], bool) {
 	// Initialize btree if required
 	if t.root == nil {
 		t.root = t.newNode()
-		t.root.items = append(t.root.items, Item[K, V]{k, v})
-		return
+		t.root.items = append(t.root.items, Item[K, V]{key: k})
+		t.length = 1
+		return &t.root.items[0], false
 	}
 	if len(t.root.items) >= t.maxItems() {
 		promotedItem, splitNode := t.split(t.root)
@@ -122,27 +259,35 @@ func (t *BTree[K, V]) Insert(k K, v V) {
 		newRoot.items = append(newRoot.items, promotedItem)
 		newRoot.children = append(newRoot.children, t.root, splitNode)
 		t.root = newRoot
+		if t.tracer != nil {
+			t.tracer.GrowRoot(newRoot.keys())
+		}
 	}
 
-	t.insert(k, v, t.root)
+	item, found := t.insert(k, t.root)
+	if !found {
+		t.length++
+	}
+	return item, found
 }
 
 /*
-Insert key, value pair into subtree rooted at n. Returns information regarding if
-the insertion resulted in a promotion, which the caller must handle
+Find or insert the item with key k in subtree rooted at n. Returns a pointer to the
+item and whether it already existed. Newly inserted items have a zero value
 */
-func (t *BTree[K, V]) insert(k K, v V, n *Node[K, V]) {
-	idx, found := n.items.find(k)
+func (t *BTree[K, V]) insert(k K, n *Node[K, V]) (*Item[K, V], bool) {
+	// The caller writes the value through the returned pointer, so every node on the path goes stale
+	n.invalidateHash()
+	idx, found := t.find(n.items, k)
 
-	// If the key already exists, replace it
 	if found {
-		n.items[idx].value = v
-		return
+		return &n.items[idx], true
 	}
 
 	if n.isLeaf() {
-		n.items.insertAt(k, v, idx)
-		return
+		var zeroVal V
+		n.items.insertAt(k, zeroVal, idx)
+		return &n.items[idx], false
 	}
 
 	next := n.children[idx]
@@ -153,22 +298,68 @@ func (t *BTree[K, V]) insert(k K, v V, n *Node[K, V]) {
 
 		// The split might change our direction
 		keyInBTree := n.items[idx].key
-		if k < keyInBTree {
+		if c := t.cmp(k, keyInBTree); c < 0 {
 			// Do nothing
-		} else if k > keyInBTree {
+		} else if c > 0 {
 			idx++
 		} else {
-			n.items[idx].value = v
-			return
+			return &n.items[idx], true
 		}
 
 	}
 
-	t.insert(k, v, n.children[idx])
+	return t.insert(k, n.children[idx])
 }
 
 /*
-Delete item with key k from btree. Returns whether the key was found
+Set the value of key k to the result of fn, which receives the current value and
+whether the key exists. The tree is only descended once. fn runs while the item is
+held in place, so it must not modify the tree
+*/
+func (t *BTree[K, V]) Upsert(k K, fn func(old V, exists bool) V) {
+	item, found := t.upsert(k)
+	item.value = fn(item.value, found)
+}
+
+/*
+Returns the value of key k if it exists, otherwise inserts v and returns it. The
+returned bool reports whether the key already existed
+*/
+func (t *BTree[K, V]) GetOrInsert(k K, v V) (V, bool) {
+	item, found := t.upsert(k)
+	if !found {
+		item.value = v
+	}
+	return item.value, found
+}
+
+/*
+Insert key,value pair into btree. Returns the previous value of the key and whether it existed
+*/
+func (t *BTree[K, V]) Replace(k K, v V) (V, bool) {
+	item, found := t.upsert(k)
+	old := item.value
+	item.value = v
+	return old, found
+}
+
+/*
+Set the value of key k to new if its current value equals old. Returns whether the value
+was swapped. The tree is only descended once, and a missing key is not inserted
+*/
+func CompareAndSwap[K cmp.Ordered, V comparable](t *BTree[K, V], k K, old, new V) bool {
+	item := t.lookupForWrite(k)
+	if item == nil || item.value != old {
+		return false
+	}
+	item.value = new
+	return true
+}
+
+/*
+Delete item with key k from btree. Returns whether the key was found. The tree is
+rebalanced on the way down before it is known whether k exists, so even when it
+does not, nodes may be merged and the tree may lose a level
 */
 func (t *BTree[K, V]) Delete(k K) bool {
 
@@ -177,19 +368,30 @@ func (t *BTree[K, V]) Delete(k K) bool {
 	}
 
 	found := t.delete(k, t.root)
-	if !found {
-		return false
-	}
 
-	// Handle shrinking of btree
+	// Handle shrinking of btree. Merges on the way down can empty the root
+	// even when the key is not found, which would leave the root without items
 	if len(t.root.items) == 0 {
+		oldRoot := t.root
 		if t.root.isLeaf() {
 			t.root = nil
 		} else {
 			t.root = t.root.children[0]
 		}
+		t.freeNode(oldRoot)
+		if t.tracer != nil {
+			var rootKeys []K
+			if t.root != nil {
+				rootKeys = t.root.keys()
+			}
+			t.tracer.ShrinkRoot(rootKeys)
+		}
 	}
 
+	if !found {
+		return false
+	}
+	t.length--
 	return true
 }
 
@@ -197,7 +399,8 @@ func (t *BTree[K, V]) Delete(k K) bool {
 Delete item with key k from subtree rooted at n. Returns whether key was found
 */
 func (t *BTree[K, V]) delete(k K, n *Node[K, V]) bool {
-	idx, found := n.items.find(k)
+	n.invalidateHash()
+	idx, found := t.find(n.items, k)
 	if found {
 		if n.isLeaf() {
 			n.items.deleteAt(idx)
@@ -220,7 +423,7 @@ func (t *BTree[K, V]) delete(k K, n *Node[K, V]) bool {
 			}
 			n.items[idx] = t.popMin(rightChild)
 		} else {
-			n.merge(idx)
+			t.merge(n, idx)
 			t.delete(k, leftChild)
 
 		}
@@ -251,19 +454,22 @@ Rebalances child at index i of node n. Returns a pointer to child i
 or its left sibling, if child i got merged into it
 */
 func (t *BTree[K, V]) rebalance(n *Node[K, V], i int) *Node[K, V] {
+	if t.tracer != nil {
+		t.tracer.Rebalance(n.keys(), i)
+	}
 
 	hasLeftSibling := i > 0
 	hasRightSibling := i < len(n.children)-1
 
 	if hasLeftSibling && len(n.children[i-1].items) > t.minItems() {
-		n.stealFromLeftSibling(i)
+		t.stealFromLeftSibling(n, i)
 	} else if hasRightSibling && len(n.children[i+1].items) > t.minItems() {
-		n.stealFromRightSibling(i)
+		t.stealFromRightSibling(n, i)
 	} else {
 		if hasRightSibling {
-			n.merge(i)
+			t.merge(n, i)
 		} else {
-			n.merge(i - 1)
+			t.merge(n, i-1)
 			// We have merged our old target into its left sibling and must change course
 			return n.children[i-1]
 		}
@@ -276,6 +482,7 @@ func (t *BTree[K, V]) rebalance(n *Node[K, V], i int) *Node[K, V] {
 Pop the max item at the btree rooted at node n, assuming that n has more than min items
 */
 func (t *BTree[K, V]) popMax(n *Node[K, V]) Item[K, V] {
+	n.invalidateHash()
 	if n.isLeaf() {
 		return n.items.deleteAt(len(n.items) - 1)
 	}
@@ -291,6 +498,7 @@ func (t *BTree[K, V]) popMax(n *Node[K, V]) Item[K, V] {
 Pop the min item at the btree rooted at node n, assuming that n has more than min items
 */
 func (t *BTree[K, V]) popMin(n *Node[K, V]) Item[K, V] {
+	n.invalidateHash()
 	if n.isLeaf() {
 		return n.items.deleteAt(0)
 	}
@@ -303,9 +511,38 @@ func (t *BTree[K, V]) popMin(n *Node[K, V]) Item[K, V] {
 	return t.popMin(next)
 }
 
+// Steals an item from the left sibling of child at index i of node n, reporting it to the tracer
+func (t *BTree[K, V]) stealFromLeftSibling(n *Node[K, V], i int) {
+	n.stealFromLeftSibling(i)
+	if t.tracer != nil {
+		t.tracer.StealFromLeft(n.items[i-1].key, n.children[i].keys(), n.children[i-1].keys())
+	}
+}
+
+// Steals an item from the right sibling of child at index i of node n, reporting it to the tracer
+func (t *BTree[K, V]) stealFromRightSibling(n *Node[K, V], i int) {
+	n.stealFromRightSibling(i)
+	if t.tracer != nil {
+		t.tracer.StealFromRight(n.items[i].key, n.children[i].keys(), n.children[i+1].keys())
+	}
+}
+
+// Merge child at index i of node n with child at index i+1, reporting it to the tracer
+func (t *BTree[K, V]) merge(n *Node[K, V], i int) {
+	separator, sibling := n.items[i].key, n.children[i+1]
+	n.merge(i)
+	if t.tracer != nil {
+		t.tracer.Merge(separator, n.children[i].keys())
+	}
+	t.freeNode(sibling)
+}
+
 // Steals an item from the left sibling of child at index i of node n
 func (n *Node[K, V]) stealFromLeftSibling(i int) {
 	child, sibling := n.children[i], n.children[i-1]
+	n.invalidateHash()
+	child.invalidateHash()
+	sibling.invalidateHash()
 	demotedItem := n.items[i-1]
 	child.items.insertAt(demotedItem.key, demotedItem.value, 0)
 	if !sibling.isLeaf() {
@@ -321,6 +558,9 @@ func (n *Node[K, V]) stealFromLeftSibling(i int) {
 // Steals an item from the right sibling of child at index i of node n
 func (n *Node[K, V]) stealFromRightSibling(i int) {
 	child, sibling := n.children[i], n.children[i+1]
+	n.invalidateHash()
+	child.invalidateHash()
+	sibling.invalidateHash()
 	child.items = append(child.items, n.items[i])
 	if !child.isLeaf() {
 		child.children = append(child.children, sibling.children.deleteAt(0))
@@ -332,6 +572,8 @@ func (n *Node[K, V]) stealFromRightSibling(i int) {
 // Merge child at index i of node n, with child at index i+1
 func (n *Node[K, V]) merge(i int) {
 	child, sibling := n.children[i], n.children[i+1]
+	n.invalidateHash()
+	child.invalidateHash()
 
 	child.items = append(child.items, n.items.deleteAt(i))
 	child.items = append(child.items, sibling.items...)
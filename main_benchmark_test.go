@@ -35,6 +35,7 @@ func BenchmarkBTreeInsert(b *testing.B) {
 					btree := NewBtree[int, int](degree)
 					kvPairs := generateRandomKVPairs(size)
 
+					b.ReportAllocs()
 					b.ResetTimer()
 					for i := 0; i < b.N; i++ {
 						for _, pair := range kvPairs {
@@ -68,6 +69,7 @@ func BenchmarkBTreeGet(b *testing.B) {
 						keys[i] = pair.key
 					}
 
+					b.ReportAllocs()
 					b.ResetTimer()
 					for i := 0; i < b.N; i++ {
 						for _, key := range keys {
@@ -101,6 +103,7 @@ func BenchmarkBTreeDelete(b *testing.B) {
 						keys[i] = pair.key
 					}
 
+					b.ReportAllocs()
 					b.ResetTimer()
 					for i := 0; i < b.N; i++ {
 						for _, key := range keys {
@@ -112,3 +115,292 @@ func BenchmarkBTreeDelete(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkBTreeChurn(b *testing.B) {
+	testSizes := []int{1000, 10000, 100000}
+	degrees := []int{2, 4, 8}
+
+	for _, freeList := range []bool{false, true} {
+		for _, degree := range degrees {
+			for _, size := range testSizes {
+				b.Run(
+					fmt.Sprintf("Churn_FreeList_%v_Degree_%v_Size_+%v", freeList, degree, size),
+					func(b *testing.B) {
+						btree := NewBtree[int, int](degree)
+						if freeList {
+							btree = NewBtreeWithFreeList(degree, NewFreeList[int, int](size))
+						}
+						kvPairs := generateRandomKVPairs(size)
+
+						b.ReportAllocs()
+						b.ResetTimer()
+						for i := 0; i < b.N; i++ {
+							for _, pair := range kvPairs {
+								btree.Insert(pair.key, pair.value)
+							}
+							for _, pair := range kvPairs {
+								btree.Delete(pair.key)
+							}
+						}
+					},
+				)
+			}
+		}
+	}
+}
+
+func BenchmarkSoABTreeInsert(b *testing.B) {
+	testSizes := []int{1000, 10000, 100000}
+	degrees := []int{2, 4, 8}
+
+	for _, degree := range degrees {
+		for _, size := range testSizes {
+			b.Run(
+				fmt.Sprintf("Insert_Degree_%v_Size_+%v", degree, size),
+				func(b *testing.B) {
+					btree := NewSoABtree[int, int](degree)
+					kvPairs := generateRandomKVPairs(size)
+
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						for _, pair := range kvPairs {
+							btree.Insert(pair.key, pair.value)
+						}
+					}
+				},
+			)
+		}
+	}
+}
+
+func BenchmarkSoABTreeGet(b *testing.B) {
+	testSizes := []int{1000, 10000, 100000}
+	degrees := []int{2, 4, 8}
+
+	for _, degree := range degrees {
+		for _, size := range testSizes {
+			b.Run(
+				fmt.Sprintf("Get_Degree_%v_Size_+%v", degree, size),
+				func(b *testing.B) {
+					btree := NewSoABtree[int, int](degree)
+					kvPairs := generateRandomKVPairs(size)
+
+					for _, pair := range kvPairs {
+						btree.Insert(pair.key, pair.value)
+					}
+
+					keys := make([]int, size)
+					for i, pair := range kvPairs {
+						keys[i] = pair.key
+					}
+
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						for _, key := range keys {
+							btree.Get(key)
+						}
+					}
+				},
+			)
+		}
+	}
+}
+
+func BenchmarkSoABTreeDelete(b *testing.B) {
+	testSizes := []int{1000, 10000, 100000}
+	degrees := []int{2, 4, 8}
+
+	for _, degree := range degrees {
+		for _, size := range testSizes {
+			b.Run(
+				fmt.Sprintf("Delete_Degree_%v_Size_+%v", degree, size),
+				func(b *testing.B) {
+					btree := NewSoABtree[int, int](degree)
+					kvPairs := generateRandomKVPairs(size)
+
+					for _, pair := range kvPairs {
+						btree.Insert(pair.key, pair.value)
+					}
+
+					keys := make([]int, size)
+					for i, pair := range kvPairs {
+						keys[i] = pair.key
+					}
+
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						for _, key := range keys {
+							btree.Delete(key)
+						}
+					}
+				},
+			)
+		}
+	}
+}
+
+// A value large enough that striding over it dominates searching a node
+type largeValue [128]byte
+
+func BenchmarkLayoutGetLargeValues(b *testing.B) {
+	testSizes := []int{1000, 10000, 100000}
+	degrees := []int{2, 4, 8, 16}
+
+	for _, degree := range degrees {
+		for _, size := range testSizes {
+			kvPairs := generateRandomKVPairs(size)
+			keys := make([]int, size)
+			for i, pair := range kvPairs {
+				keys[i] = pair.key
+			}
+
+			b.Run(
+				fmt.Sprintf("AoS_Degree_%v_Size_+%v", degree, size),
+				func(b *testing.B) {
+					btree := NewBtree[int, largeValue](degree)
+					for _, pair := range kvPairs {
+						btree.Insert(pair.key, largeValue{})
+					}
+
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						for _, key := range keys {
+							btree.Get(key)
+						}
+					}
+				},
+			)
+			b.Run(
+				fmt.Sprintf("SoA_Degree_%v_Size_+%v", degree, size),
+				func(b *testing.B) {
+					btree := NewSoABtree[int, largeValue](degree)
+					for _, pair := range kvPairs {
+						btree.Insert(pair.key, largeValue{})
+					}
+
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						for _, key := range keys {
+							btree.Get(key)
+						}
+					}
+				},
+			)
+		}
+	}
+}
+
+func BenchmarkFrozenBTreeGet(b *testing.B) {
+	testSizes := []int{1000, 10000, 100000}
+
+	for _, size := range testSizes {
+		b.Run(
+			fmt.Sprintf("Get_Size_+%v", size),
+			func(b *testing.B) {
+				btree := NewBtree[int, int](8)
+				kvPairs := generateRandomKVPairs(size)
+
+				for _, pair := range kvPairs {
+					btree.Insert(pair.key, pair.value)
+				}
+				frozen := btree.Freeze()
+
+				keys := make([]int, size)
+				for i, pair := range kvPairs {
+					keys[i] = pair.key
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					for _, key := range keys {
+						frozen.Get(key)
+					}
+				}
+			},
+		)
+	}
+}
+
+func BenchmarkLayoutGetPathKeys(b *testing.B) {
+	const size = 100000
+	keys := make([]string, size)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("/srv/data/tenants/acme-corporation/projects/infrastructure/objects/%010d", rand.Intn(1<<30))
+	}
+
+	b.Run("BTree", func(b *testing.B) {
+		btree := NewBtree[string, int](16)
+		for i, k := range keys {
+			btree.Insert(k, i)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, k := range keys {
+				btree.Get(k)
+			}
+		}
+	})
+
+	b.Run("PrefixBTree", func(b *testing.B) {
+		btree := NewPrefixBtree[int](16)
+		for i, k := range keys {
+			btree.Insert(k, i)
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, k := range keys {
+				btree.Get(k)
+			}
+		}
+	})
+}
+
+func BenchmarkBufferedIngest(b *testing.B) {
+	existing := generateRandomKVPairs(1_000_000)
+
+	// Bursts of random keys, and of keys in a few short runs, as from a handful of sequential writers
+	random := generateRandomKVPairs(200_000)
+	clustered := generateRandomKVPairs(200_000)
+	for i := range clustered {
+		clustered[i].key = clustered[i%16].key + i/16
+	}
+	bursts := []struct {
+		name  string
+		pairs []struct{ key, value int }
+	}{{"Random", random}, {"Clustered", clustered}}
+
+	for _, burst := range bursts {
+		for _, bufferSize := range []int{0, 4096, 65536} {
+			b.Run(fmt.Sprintf("%v_Buffer_%v", burst.name, bufferSize), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					tree := NewBufferedBtree[int, int](32, max(bufferSize, 1))
+					for _, pair := range existing {
+						tree.tree.Insert(pair.key, pair.value)
+					}
+					b.StartTimer()
+
+					for _, pair := range burst.pairs {
+						if bufferSize == 0 {
+							tree.tree.Insert(pair.key, pair.value)
+						} else {
+							tree.Insert(pair.key, pair.value)
+						}
+					}
+					tree.Flush()
+				}
+			})
+		}
+	}
+}
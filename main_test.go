@@ -1,108 +1,13 @@
 package btree
 
 import (
-	"cmp"
 	"fmt"
 	"math"
 	"math/rand/v2"
-	"slices"
 	"strconv"
 	"testing"
 )
 
-func (btree *BTree[K, V]) checkTreeValid(node *Node[K, V], t *testing.T) bool {
-	if node == nil {
-		return true
-	}
-	isLeaf := len(node.children) == 0
-	isRoot := btree.root == node
-
-	valid := true
-
-	if len(node.children) > btree.maxChildren() {
-		t.Errorf("Node has too many children: %+v", *node)
-		valid = false
-	}
-
-	if len(node.items) < btree.minItems() && !isRoot {
-		t.Errorf("Node has too few items: %+v", *node)
-		valid = false
-	}
-
-	if len(node.items) > btree.maxItems() {
-		t.Errorf("Node has too many keys: %+v", *node)
-		valid = false
-	}
-
-	isItemsSorted := slices.IsSortedFunc(node.items, func(a Item[K, V], b Item[K, V]) int {
-		return cmp.Compare(a.key, b.key)
-	})
-	if !isItemsSorted {
-		t.Errorf("Items of node are not sorted: %+v", *node)
-		valid = false
-	}
-
-	if !node.hasValidKeyChildRatio() && !isLeaf && !isRoot {
-		t.Errorf("Node doesn't have valid number of children vs items: %+v", *node)
-		valid = false
-	}
-
-	if !node.isLeaf() {
-		for idx, item := range node.items {
-			for _, predecessor := range node.children[idx].items {
-				if predecessor.key >= item.key {
-					t.Errorf("Predecessor %v is larger than item %v", predecessor.key, item.key)
-					valid = false
-
-				}
-			}
-
-			for _, successor := range node.children[idx+1].items {
-				if successor.key <= item.key {
-					t.Errorf("Successor %v is smaller than item %v", successor.key, item.key)
-					valid = false
-				}
-			}
-		}
-	}
-
-	for _, child := range node.children {
-		if !btree.checkTreeValid(child, t) {
-			valid = false
-		}
-	}
-
-	return valid
-}
-
-func (btree *BTree[K, V]) _hasValidDepth(node *Node[K, V], depth int, leafDepth *int) bool {
-	if btree.root == nil {
-		return true
-	}
-	if len(node.children) == 0 {
-		if *leafDepth == -1 {
-			*leafDepth = depth
-		}
-		return depth == *leafDepth
-	}
-
-	for _, child := range node.children {
-		if !btree._hasValidDepth(child, depth+1, leafDepth) {
-			return false
-		}
-	}
-	return true
-}
-
-func (btree *BTree[K, V]) hasValidDepth(t *testing.T) bool {
-	leafDepth := -1
-	if !btree._hasValidDepth(btree.root, 0, &leafDepth) {
-		t.Errorf("BTree does not have a valid depth")
-		return false
-	}
-	return true
-}
-
 func TestBTreeGet(t *testing.T) {
 	for d := 2; d < 10; d++ {
 		btree := NewBtree[int, string](d)
@@ -136,8 +41,9 @@ func TestBTreeGet(t *testing.T) {
 				if found && val != test.expected {
 					t.Errorf("Get(%d) = %v; want %v", test.key, val, test.expected)
 				}
-				btree.checkTreeValid(btree.root, t)
-				btree.hasValidDepth(t)
+				if err := btree.Validate(); err != nil {
+					t.Error(err)
+				}
 
 			}
 		})
@@ -171,12 +77,10 @@ func TestBTreeRandomInserts(t *testing.T) {
 					btree.Insert(test.key, test.value)
 					t.Logf("State:\n%v", btree.String())
 
-					treeValid := btree.checkTreeValid(btree.root, t)
-					validDepth := btree.hasValidDepth(t)
 					fail := false
-					if !treeValid || !validDepth {
+					if err := btree.Validate(); err != nil {
 						fail = true
-						t.Error("Tree is not valid!")
+						t.Errorf("Tree is not valid: %v", err)
 					}
 
 					val, found := btree.Get(test.key)
@@ -239,12 +143,10 @@ func TestBTreeRandomDeletes(t *testing.T) {
 
 					_, found := btree.Get(test.key)
 
-					treeValid := btree.checkTreeValid(btree.root, t)
-					validDepth := btree.hasValidDepth(t)
 					fail := false
-					if !treeValid || !validDepth {
+					if err := btree.Validate(); err != nil {
 						fail = true
-						t.Error("Tree is not valid!")
+						t.Errorf("Tree is not valid: %v", err)
 					}
 
 					if found {
@@ -266,3 +168,217 @@ func TestBTreeRandomDeletes(t *testing.T) {
 		}
 	}
 }
+
+func TestBTreeLen(t *testing.T) {
+	r := rand.NewPCG(424242, 1024)
+	random := rand.New(r)
+
+	for d := 2; d < 10; d++ {
+		btree := NewBtree[int, int](d)
+		expected := make(map[int]bool)
+
+		t.Run(fmt.Sprintf("Len at degree %v", d), func(t *testing.T) {
+			for range 1000 {
+				key := random.IntN(100)
+				if random.IntN(2) == 0 {
+					btree.Insert(key, key)
+					expected[key] = true
+				} else {
+					btree.Delete(key)
+					delete(expected, key)
+				}
+
+				if btree.Len() != len(expected) {
+					t.Fatalf("Len() = %v; expected %v", btree.Len(), len(expected))
+				}
+			}
+		})
+	}
+}
+
+func TestBTreeMinMax(t *testing.T) {
+	btree := NewBtree[int, string](2)
+
+	if _, _, found := btree.Min(); found {
+		t.Errorf("Min() found an item in an empty tree")
+	}
+	if _, _, found := btree.Max(); found {
+		t.Errorf("Max() found an item in an empty tree")
+	}
+
+	for _, k := range []int{50, 10, 90, 30, 70, 20, 80} {
+		btree.Insert(k, strconv.Itoa(k))
+	}
+
+	if k, v, found := btree.Min(); !found || k != 10 || v != "10" {
+		t.Errorf("Min() = (%v, %v, %v); expected (10, 10, true)", k, v, found)
+	}
+	if k, v, found := btree.Max(); !found || k != 90 || v != "90" {
+		t.Errorf("Max() = (%v, %v, %v); expected (90, 90, true)", k, v, found)
+	}
+}
+
+func TestBTreeFloorCeiling(t *testing.T) {
+	for d := 2; d < 6; d++ {
+		btree := NewBtree[int, int](d)
+		for i := 0; i <= 100; i += 10 {
+			btree.Insert(i, i)
+		}
+
+		tests := []struct {
+			key          int
+			floor        int
+			floorFound   bool
+			ceiling      int
+			ceilingFound bool
+		}{
+			{-5, 0, false, 0, true},
+			{0, 0, true, 0, true},
+			{15, 10, true, 20, true},
+			{50, 50, true, 50, true},
+			{99, 90, true, 100, true},
+			{105, 100, true, 0, false},
+		}
+
+		t.Run(fmt.Sprintf("Floor and Ceiling at degree %v", d), func(t *testing.T) {
+			for _, test := range tests {
+				k, _, found := btree.Floor(test.key)
+				if found != test.floorFound || (found && k != test.floor) {
+					t.Errorf("Floor(%d) = (%v, %v); expected (%v, %v)", test.key, k, found, test.floor, test.floorFound)
+				}
+				k, _, found = btree.Ceiling(test.key)
+				if found != test.ceilingFound || (found && k != test.ceiling) {
+					t.Errorf("Ceiling(%d) = (%v, %v); expected (%v, %v)", test.key, k, found, test.ceiling, test.ceilingFound)
+				}
+			}
+		})
+	}
+}
+
+func TestBTreeUpsert(t *testing.T) {
+	for d := 2; d < 6; d++ {
+		btree := NewBtree[int, int](d)
+
+		t.Run(fmt.Sprintf("Upsert at degree %v", d), func(t *testing.T) {
+			for i := range 1000 {
+				btree.Upsert(i%37, func(old int, exists bool) int {
+					if exists != (i >= 37) {
+						t.Errorf("Upsert(%d) exists = %v; expected %v", i%37, exists, i >= 37)
+					}
+					return old + 1
+				})
+			}
+
+			for k := range 37 {
+				expected := 1000 / 37
+				if k < 1000%37 {
+					expected++
+				}
+				if val, _ := btree.Get(k); val != expected {
+					t.Errorf("Get(%d) = %v; expected %v", k, val, expected)
+				}
+			}
+			if btree.Len() != 37 {
+				t.Errorf("Len() = %v; expected 37", btree.Len())
+			}
+		})
+	}
+}
+
+func TestBTreeGetOrInsert(t *testing.T) {
+	btree := NewBtree[string, int](2)
+
+	if val, found := btree.GetOrInsert("a", 1); found || val != 1 {
+		t.Errorf("GetOrInsert(a, 1) = (%v, %v); expected (1, false)", val, found)
+	}
+	if val, found := btree.GetOrInsert("a", 2); !found || val != 1 {
+		t.Errorf("GetOrInsert(a, 2) = (%v, %v); expected (1, true)", val, found)
+	}
+	if val, _ := btree.Get("a"); val != 1 {
+		t.Errorf("Get(a) = %v; expected 1", val)
+	}
+}
+
+func TestBTreeReplace(t *testing.T) {
+	btree := NewBtree[int, string](2)
+	for i := range 20 {
+		btree.Insert(i, strconv.Itoa(i))
+	}
+
+	if old, found := btree.Replace(5, "five"); !found || old != "5" {
+		t.Errorf("Replace(5) = (%v, %v); expected (5, true)", old, found)
+	}
+	if old, found := btree.Replace(50, "fifty"); found || old != "" {
+		t.Errorf("Replace(50) = (%v, %v); expected (, false)", old, found)
+	}
+	if val, _ := btree.Get(5); val != "five" {
+		t.Errorf("Get(5) = %v; expected five", val)
+	}
+	if btree.Len() != 21 {
+		t.Errorf("Len() = %v; expected 21", btree.Len())
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	btree := NewBtree[int, string](2)
+	for i := range 20 {
+		btree.Insert(i, strconv.Itoa(i))
+	}
+
+	tests := []struct {
+		key      int
+		old      string
+		new      string
+		swapped  bool
+		expected string
+	}{
+		{3, "3", "three", true, "three"},
+		{3, "3", "drei", false, "three"},
+		{4, "5", "four", false, "4"},
+		{100, "", "hundred", false, ""},
+	}
+
+	for _, test := range tests {
+		if swapped := CompareAndSwap(btree, test.key, test.old, test.new); swapped != test.swapped {
+			t.Errorf("CompareAndSwap(%d, %v, %v) = %v; expected %v", test.key, test.old, test.new, swapped, test.swapped)
+		}
+		if val, _ := btree.Get(test.key); val != test.expected {
+			t.Errorf("Get(%d) = %v; expected %v", test.key, val, test.expected)
+		}
+	}
+	if _, found := btree.Get(100); found {
+		t.Errorf("CompareAndSwap inserted a missing key")
+	}
+}
+
+func TestBTreeDeleteMissingShrinksRoot(t *testing.T) {
+	btree := NewBtree[int, int](2)
+	for _, k := range []int{10, 20, 30, 40} {
+		btree.Insert(k, k)
+	}
+	btree.Delete(40)
+	if height := btree.Stats().Height; height != 2 {
+		t.Fatalf("Height = %v before deleting a missing key; expected 2", height)
+	}
+
+	// The root now has a single item and two minimal children, so looking
+	// for a missing key merges them into the root's only child, which
+	// becomes the root
+	if btree.Delete(15) {
+		t.Errorf("Deleted non-existing key")
+	}
+	if err := btree.Validate(); err != nil {
+		t.Error(err)
+	}
+	if height := btree.Stats().Height; height != 1 {
+		t.Errorf("Height = %v after deleting a missing key; expected the root to shrink to 1", height)
+	}
+	if btree.Len() != 3 {
+		t.Errorf("Len() = %v after deleting a missing key; expected 3", btree.Len())
+	}
+	for _, k := range []int{10, 20, 30} {
+		if v, found := btree.Get(k); !found || v != k {
+			t.Errorf("Get(%v) = (%v, %v) after deleting a missing key; expected (%v, true)", k, v, found, k)
+		}
+	}
+}
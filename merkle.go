@@ -0,0 +1,405 @@
+package btree
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"iter"
+	"math"
+	"reflect"
+)
+
+/*
+ItemHasher writes an encoding of key k and value v to w, which feeds the Merkle
+hash of the node holding the item. The encoding must be deterministic and
+self-delimiting, so that no two distinct items or runs of items encode the same
+*/
+type ItemHasher[K cmp.Ordered, V any] func(w io.Writer, k K, v V)
+
+type merkleHash struct {
+	sum   [sha256.Size]byte
+	valid bool
+}
+
+// Markers separating the hash of a leaf from that of an internal node
+const (
+	merkleLeaf     = 0
+	merkleInternal = 1
+)
+
+/*
+Set the encoding of items used by RootHash and Diff. A nil hasher restores the
+default, which encodes strings, byte slices, bools and the common number types
+directly, and other keys and values, as well as values of interface types, in Go
+syntax with the %#v verb of package fmt. That is unambiguous for values built
+from numbers, strings, bools, arrays, slices, maps and structs. It is not for
+pointers, which encode as addresses, for interfaces holding numbers of different
+types, as int 1 and float 1.0 encode the same, or for GoString methods which
+lose information. Trees of such values need an ItemHasher. Cached hashes are
+discarded
+*/
+func (t *BTree[K, V]) SetItemHasher(hashItem ItemHasher[K, V]) {
+	t.hashItem = hashItem
+	if t.root != nil {
+		t.discardHashes(t.root)
+	}
+}
+
+/*
+Returns the SHA-256 Merkle hash of the tree. The hash of a node covers its items
+and the hashes of its children, so equal hashes mean equal items and equal
+shape, as long as the items are encoded unambiguously (see SetItemHasher). Trees
+holding the same items in different shapes, as built by different degrees or
+insertion orders, hash differently.
+
+Hashes are cached per node and only recomputed for nodes modified since the
+last call, so after a few changes this costs about one node per change and level
+*/
+func (t *BTree[K, V]) RootHash() [sha256.Size]byte {
+	if t.root == nil {
+		return sha256.Sum256(nil)
+	}
+	return t.nodeHash(t.root, sha256.New())
+}
+
+/*
+Returns the hash of the subtree rooted at n, computing and caching the hashes of
+stale nodes. h is used as scratch state
+*/
+func (t *BTree[K, V]) nodeHash(n *Node[K, V], h hash.Hash) [sha256.Size]byte {
+	if n.merkle != nil && n.merkle.valid {
+		return n.merkle.sum
+	}
+	for _, child := range n.children {
+		t.nodeHash(child, h)
+	}
+
+	h.Reset()
+	if n.isLeaf() {
+		h.Write([]byte{merkleLeaf})
+	} else {
+		h.Write([]byte{merkleInternal})
+	}
+	for i, item := range n.items {
+		if !n.isLeaf() {
+			h.Write(n.children[i].merkle.sum[:])
+		}
+		t.writeItem(h, item)
+	}
+	if !n.isLeaf() {
+		h.Write(n.children[len(n.children)-1].merkle.sum[:])
+	}
+
+	if n.merkle == nil {
+		n.merkle = &merkleHash{}
+	}
+	h.Sum(n.merkle.sum[:0])
+	n.merkle.valid = true
+	return n.merkle.sum
+}
+
+func (t *BTree[K, V]) writeItem(w io.Writer, item Item[K, V]) {
+	if t.hashItem != nil {
+		t.hashItem(w, item.key, item.value)
+		return
+	}
+	defaultItemHasher(w, item.key, item.value)
+}
+
+// Writes the encodings of k and v, each preceded by its length
+func defaultItemHasher[K cmp.Ordered, V any](w io.Writer, k K, v V) {
+	key := encodeHashField(k)
+	w.Write(binary.AppendUvarint(nil, uint64(len(key))))
+	w.Write(key)
+
+	// Values of an interface type may hold different types, which only Go syntax tells apart
+	var value []byte
+	if reflect.TypeFor[V]().Kind() == reflect.Interface {
+		value = fmt.Appendf(nil, "%#v", v)
+	} else {
+		value = encodeHashField(v)
+	}
+	w.Write(binary.AppendUvarint(nil, uint64(len(value))))
+	w.Write(value)
+}
+
+/*
+Encodes a key or value for hashing. Every key, and every value, of a tree has the
+same type, so the encoding need not identify the type
+*/
+func encodeHashField(field any) []byte {
+	switch f := field.(type) {
+	case string:
+		return []byte(f)
+	case []byte:
+		return f
+	case bool:
+		if f {
+			return []byte{1}
+		}
+		return []byte{0}
+	case int:
+		return binary.AppendVarint(nil, int64(f))
+	case int64:
+		return binary.AppendVarint(nil, f)
+	case uint:
+		return binary.AppendUvarint(nil, uint64(f))
+	case uint64:
+		return binary.AppendUvarint(nil, f)
+	case float64:
+		return binary.BigEndian.AppendUint64(nil, math.Float64bits(f))
+	default:
+		return fmt.Appendf(nil, "%#v", f)
+	}
+}
+
+// Marks the cached hash of n stale. Every ancestor of n must be marked as well
+func (n *Node[K, V]) invalidateHash() {
+	if n.merkle != nil {
+		n.merkle.valid = false
+	}
+}
+
+/*
+Like lookup, but marks the cached hashes on the path to key k stale, for changes
+which write through the returned pointer
+*/
+func (t *BTree[K, V]) lookupForWrite(k K) *Item[K, V] {
+	for n := t.root; n != nil; {
+		n.invalidateHash()
+		idx, found := t.find(n.items, k)
+		if found {
+			return &n.items[idx]
+		}
+		if n.isLeaf() {
+			return nil
+		}
+		n = n.children[idx]
+	}
+	return nil
+}
+
+// Marks the cached hashes of every node in the subtree rooted at n stale
+func (t *BTree[K, V]) discardHashes(n *Node[K, V]) {
+	n.invalidateHash()
+	for _, child := range n.children {
+		t.discardHashes(child)
+	}
+}
+
+type DiffKind int
+
+const (
+	// The key is only in the new tree
+	DiffAdded DiffKind = iota
+	// The key is only in the old tree
+	DiffRemoved
+	// The key is in both trees with values which hash differently
+	DiffChanged
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	case DiffChanged:
+		return "changed"
+	}
+	return fmt.Sprintf("DiffKind(%d)", int(k))
+}
+
+/*
+A difference between two trees. Old is the zero value for added keys, and New
+is the zero value for removed keys
+*/
+type DiffEntry[K cmp.Ordered, V any] struct {
+	Kind DiffKind
+	Key  K
+	Old  V
+	New  V
+}
+
+/*
+Returns an iterator over the differences between a and b in ascending key
+order, as the changes turning a into b. Values are compared by the item hasher
+of a, and both trees must share the same key ordering.
+
+Both trees are walked in order, and subtrees with equal Merkle hashes starting
+at the same key are skipped whole. Replicas built by mostly the same operations
+share most of their subtrees, so only the paths to changed keys are visited.
+Trees of different shapes are still compared correctly, item by item. Subtree
+hashes are only comparable if both trees use the same item hasher, so trees with
+different custom hashers must not be diffed. If only one of them has a custom
+hasher, no subtrees are skipped. Neither tree may be modified while iterating
+*/
+func Diff[K cmp.Ordered, V any](a, b *BTree[K, V]) iter.Seq[DiffEntry[K, V]] {
+	return func(yield func(DiffEntry[K, V]) bool) {
+		a.RootHash()
+		b.RootHash()
+		// Functions cannot be compared, so only a custom hasher on one side is detected
+		sameHasher := (a.hashItem == nil) == (b.hashItem == nil)
+		d := differ[K, V]{a: a, b: b, h: sha256.New(), sameHasher: sameHasher, yield: yield}
+		d.run(newDiffCursor(a), newDiffCursor(b))
+	}
+}
+
+type differ[K cmp.Ordered, V any] struct {
+	a, b *BTree[K, V]
+	h    hash.Hash
+	// Whether subtree hashes of a and b are comparable
+	sameHasher bool
+	yield      func(DiffEntry[K, V]) bool
+}
+
+/*
+A subtree or a single item, as pending in a diffCursor. Items have a nil node.
+Leaves have height 0
+*/
+type diffElem[K cmp.Ordered, V any] struct {
+	node   *Node[K, V]
+	height int
+	item   Item[K, V]
+}
+
+/*
+Walks a tree in order, one subtree or item at a time. Subtrees are only
+expanded into their children and items on request, so the differ can skip them
+whole
+*/
+type diffCursor[K cmp.Ordered, V any] struct {
+	// Pending elements in descending key order, so the next one is last
+	stack []diffElem[K, V]
+}
+
+func newDiffCursor[K cmp.Ordered, V any](t *BTree[K, V]) *diffCursor[K, V] {
+	c := &diffCursor[K, V]{}
+	if t.root != nil {
+		height := 0
+		for n := t.root; !n.isLeaf(); n = n.children[0] {
+			height++
+		}
+		c.stack = append(c.stack, diffElem[K, V]{node: t.root, height: height})
+	}
+	return c
+}
+
+// Returns the next element and its smallest key. Reports false once the cursor is exhausted
+func (c *diffCursor[K, V]) front() (diffElem[K, V], K, bool) {
+	if len(c.stack) == 0 {
+		var zero K
+		return diffElem[K, V]{}, zero, false
+	}
+	e := c.stack[len(c.stack)-1]
+	if e.node == nil {
+		return e, e.item.key, true
+	}
+	n := e.node
+	for !n.isLeaf() {
+		n = n.children[0]
+	}
+	return e, n.items[0].key, true
+}
+
+func (c *diffCursor[K, V]) pop() {
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+// Replaces the subtree in front with its children and items
+func (c *diffCursor[K, V]) expand() {
+	e := c.stack[len(c.stack)-1]
+	c.pop()
+	n := e.node
+	for i := len(n.items) - 1; i >= 0; i-- {
+		if !n.isLeaf() {
+			c.stack = append(c.stack, diffElem[K, V]{node: n.children[i+1], height: e.height - 1})
+		}
+		c.stack = append(c.stack, diffElem[K, V]{item: n.items[i]})
+	}
+	if !n.isLeaf() {
+		c.stack = append(c.stack, diffElem[K, V]{node: n.children[0], height: e.height - 1})
+	}
+}
+
+/*
+Yields the differences between the remaining elements of ca, walking a, and cb,
+walking b. Subtrees starting at the same key with equal hashes hold the same
+items and are skipped, if the trees share a hasher. Other subtrees are expanded until their items can be
+compared
+*/
+func (d *differ[K, V]) run(ca, cb *diffCursor[K, V]) {
+	for {
+		ea, ka, okA := ca.front()
+		eb, kb, okB := cb.front()
+
+		var c int
+		switch {
+		case !okA && !okB:
+			return
+		case !okA:
+			c = 1
+		case !okB:
+			c = -1
+		default:
+			c = d.a.cmp(ka, kb)
+		}
+
+		switch {
+		case c < 0 && ea.node != nil:
+			ca.expand()
+		case c < 0:
+			if !d.yield(DiffEntry[K, V]{Kind: DiffRemoved, Key: ea.item.key, Old: ea.item.value}) {
+				return
+			}
+			ca.pop()
+		case c > 0 && eb.node != nil:
+			cb.expand()
+		case c > 0:
+			if !d.yield(DiffEntry[K, V]{Kind: DiffAdded, Key: eb.item.key, New: eb.item.value}) {
+				return
+			}
+			cb.pop()
+		case ea.node != nil && eb.node != nil && d.sameHasher && ea.node.merkle.sum == eb.node.merkle.sum:
+			ca.pop()
+			cb.pop()
+		case ea.node != nil && eb.node != nil:
+			// Expand the taller subtree, or both, so that equal subtrees line up again
+			if ea.height >= eb.height {
+				ca.expand()
+			}
+			if eb.height >= ea.height {
+				cb.expand()
+			}
+		case ea.node != nil:
+			ca.expand()
+		case eb.node != nil:
+			cb.expand()
+		default:
+			if !d.diffItems(ea.item, eb.item) {
+				return
+			}
+			ca.pop()
+			cb.pop()
+		}
+	}
+}
+
+// Yields a change if the values of x and y, which have equal keys, hash differently
+func (d *differ[K, V]) diffItems(x, y Item[K, V]) bool {
+	if d.itemHash(x) == d.itemHash(y) {
+		return true
+	}
+	return d.yield(DiffEntry[K, V]{Kind: DiffChanged, Key: x.key, Old: x.value, New: y.value})
+}
+
+func (d *differ[K, V]) itemHash(item Item[K, V]) [sha256.Size]byte {
+	var sum [sha256.Size]byte
+	d.h.Reset()
+	d.a.writeItem(d.h, item)
+	d.h.Sum(sum[:0])
+	return sum
+}
@@ -0,0 +1,279 @@
+package btree
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// Computes the root hash of tree from scratch, ignoring cached hashes
+func freshRootHash[K cmp.Ordered, V any](tree *BTree[K, V]) [32]byte {
+	if tree.root != nil {
+		tree.discardHashes(tree.root)
+	}
+	return tree.RootHash()
+}
+
+func TestRootHashEquality(t *testing.T) {
+	a := NewBtree[int, string](3)
+	b := NewBtree[int, string](3)
+	if a.RootHash() != b.RootHash() {
+		t.Errorf("empty trees hash differently")
+	}
+	for i := range 200 {
+		a.Insert(i, fmt.Sprint(i))
+		b.Insert(i, fmt.Sprint(i))
+	}
+	if a.RootHash() != b.RootHash() {
+		t.Fatalf("trees built by the same operations hash differently")
+	}
+
+	before := a.RootHash()
+	a.Insert(100, "changed")
+	if a.RootHash() == before {
+		t.Errorf("changing a value did not change the root hash")
+	}
+	a.Insert(100, "100")
+	if a.RootHash() != before {
+		t.Errorf("restoring a value did not restore the root hash")
+	}
+
+	// Keys and values must not run into each other
+	c := NewBtree[string, string](2)
+	d := NewBtree[string, string](2)
+	c.Insert("ab", "c")
+	d.Insert("a", "bc")
+	if c.RootHash() == d.RootHash() {
+		t.Errorf("items (ab, c) and (a, bc) hash the same")
+	}
+}
+
+func TestRootHashCompositeValues(t *testing.T) {
+	type pair struct {
+		A, B string
+	}
+	tests := []struct {
+		name string
+		a, b any
+	}{
+		{"slices", []string{"a b"}, []string{"a", "b"}},
+		{"nested slices", [][]int{{1, 2}, {3}}, [][]int{{1}, {2, 3}}},
+		{"structs", pair{"a", "b c"}, pair{"a b", "c"}},
+		{"maps", map[string]string{"a": "b c"}, map[string]string{"a b": "c"}},
+		{"nil and empty", []int(nil), []int{}},
+		{"dynamic types", "\x02", 1},
+	}
+
+	for _, test := range tests {
+		a := NewBtree[int, any](2)
+		b := NewBtree[int, any](2)
+		a.Insert(1, test.a)
+		b.Insert(1, test.b)
+		if a.RootHash() == b.RootHash() {
+			t.Errorf("%v: %#v and %#v hash the same", test.name, test.a, test.b)
+		}
+
+		c := NewBtree[int, any](2)
+		c.Insert(1, test.a)
+		if a.RootHash() != c.RootHash() {
+			t.Errorf("%v: %#v hashes differently in equal trees", test.name, test.a)
+		}
+	}
+}
+
+func TestRootHashIncremental(t *testing.T) {
+	for _, degree := range []int{2, 3, 5} {
+		t.Run(fmt.Sprintf("degree %v", degree), func(t *testing.T) {
+			tree := NewBtreeWithFreeList[int, int](degree, NewFreeList[int, int](32))
+			r := rand.New(rand.NewPCG(424242, uint64(degree)))
+
+			for i := range 3000 {
+				k := r.IntN(300)
+				switch r.IntN(6) {
+				case 0, 1:
+					tree.Delete(k)
+				case 2:
+					tree.Upsert(k, func(old int, _ bool) int { return old + 1 })
+				case 3:
+					CompareAndSwap(tree, k, k, -k)
+				case 4:
+					tree.GetOrInsert(k, i)
+				default:
+					tree.Insert(k, i)
+				}
+
+				if i%20 == 0 {
+					cached := tree.RootHash()
+					if fresh := freshRootHash(tree); cached != fresh {
+						t.Fatalf("cached root hash is stale after operation %v", i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSetItemHasher(t *testing.T) {
+	tree := NewBtree[int, int](2)
+	for i := range 50 {
+		tree.Insert(i, i)
+	}
+	before := tree.RootHash()
+
+	calls := 0
+	tree.SetItemHasher(func(w io.Writer, k, v int) {
+		calls++
+		w.Write(binary.AppendVarint(binary.AppendVarint(nil, int64(k)), int64(v)))
+	})
+	if tree.RootHash() == before || calls != 50 {
+		t.Errorf("hash did not change with the item hasher, or it was called %v times; expected 50", calls)
+	}
+
+	// Only the path to the changed key is rehashed
+	calls = 0
+	tree.Insert(25, -1)
+	tree.RootHash()
+	if calls == 0 || calls > 3*tree.Stats().Height {
+		t.Errorf("rehashing after one change called the item hasher %v times", calls)
+	}
+
+	tree.SetItemHasher(nil)
+	if tree.RootHash() == before {
+		t.Errorf("default hasher gives the changed tree its original hash")
+	}
+}
+
+func diffModel[K cmp.Ordered, V comparable](a, b map[K]V) map[K]DiffEntry[K, V] {
+	entries := make(map[K]DiffEntry[K, V])
+	for k, v := range a {
+		if w, ok := b[k]; !ok {
+			entries[k] = DiffEntry[K, V]{Kind: DiffRemoved, Key: k, Old: v}
+		} else if v != w {
+			entries[k] = DiffEntry[K, V]{Kind: DiffChanged, Key: k, Old: v, New: w}
+		}
+	}
+	for k, w := range b {
+		if _, ok := a[k]; !ok {
+			entries[k] = DiffEntry[K, V]{Kind: DiffAdded, Key: k, New: w}
+		}
+	}
+	return entries
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name             string
+		degreeA, degreeB int
+		changes          int
+	}{
+		{"identical", 3, 3, 0},
+		{"few changes", 3, 3, 5},
+		{"many changes", 2, 2, 500},
+		{"different shapes", 2, 7, 50},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := rand.New(rand.NewPCG(424242, 1024))
+			a := NewBtree[int, int](test.degreeA)
+			b := NewBtree[int, int](test.degreeB)
+			modelA, modelB := make(map[int]int), make(map[int]int)
+			for i := range 2000 {
+				k := r.IntN(4000)
+				a.Insert(k, i)
+				b.Insert(k, i)
+				modelA[k], modelB[k] = i, i
+			}
+			for i := range test.changes {
+				k := r.IntN(4000)
+				switch r.IntN(3) {
+				case 0:
+					b.Delete(k)
+					delete(modelB, k)
+				case 1:
+					a.Delete(k)
+					delete(modelA, k)
+				default:
+					b.Insert(k, -i)
+					modelB[k] = -i
+				}
+			}
+
+			expected := diffModel(modelA, modelB)
+			var keys []int
+			for e := range Diff(a, b) {
+				if e != expected[e.Key] {
+					t.Errorf("Diff yielded %+v; expected %+v", e, expected[e.Key])
+				}
+				delete(expected, e.Key)
+				keys = append(keys, e.Key)
+			}
+			if len(expected) != 0 {
+				t.Errorf("Diff missed %v differences", len(expected))
+			}
+			if !slices.IsSorted(keys) {
+				t.Errorf("Diff yielded keys out of order")
+			}
+		})
+	}
+}
+
+func TestDiffSkipsIdenticalSubtrees(t *testing.T) {
+	a := NewBtree[int, int](8)
+	b := NewBtree[int, int](8)
+	for i := range 10_000 {
+		a.Insert(i, i)
+		b.Insert(i, i)
+	}
+	b.Insert(5000, -1)
+	b.Delete(7000)
+	a.RootHash()
+	b.RootHash()
+
+	calls := 0
+	hasher := func(w io.Writer, k, v int) {
+		calls++
+		defaultItemHasher(w, k, v)
+	}
+	a.hashItem, b.hashItem = hasher, hasher
+
+	var entries []DiffEntry[int, int]
+	for e := range Diff(a, b) {
+		entries = append(entries, e)
+	}
+	expected := []DiffEntry[int, int]{
+		{Kind: DiffChanged, Key: 5000, Old: 5000, New: -1},
+		{Kind: DiffRemoved, Key: 7000, Old: 7000},
+	}
+	if !slices.Equal(entries, expected) {
+		t.Errorf("Diff() = %+v; expected %+v", entries, expected)
+	}
+	if calls > 200 {
+		t.Errorf("Diff hashed %v items of 10000; expected identical subtrees to be skipped", calls)
+	}
+}
+
+func TestDiffStopsEarly(t *testing.T) {
+	a := NewBtree[int, int](2)
+	b := NewBtree[int, int](3)
+	for i := range 100 {
+		b.Insert(i, i)
+	}
+	count := 0
+	for e := range Diff(a, b) {
+		if e.Kind != DiffAdded {
+			t.Errorf("Diff yielded %v; expected only added keys", e.Kind)
+		}
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("Diff did not stop after 3 entries")
+	}
+}
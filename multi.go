@@ -0,0 +1,104 @@
+package btree
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// MultiBTree is a BTree which allows duplicate keys. Values of the same key are
+// kept in insertion order
+type MultiBTree[K cmp.Ordered, V any] struct {
+	tree   *BTree[K, []V]
+	length int
+}
+
+func NewMultiBtree[K cmp.Ordered, V any](degree int) *MultiBTree[K, V] {
+	return &MultiBTree[K, V]{tree: NewBtree[K, []V](degree)}
+}
+
+/*
+Insert key,value pair. Existing values of the key are kept
+*/
+func (t *MultiBTree[K, V]) Insert(k K, v V) {
+	t.tree.Upsert(k, func(values []V, exists bool) []V {
+		return append(values, v)
+	})
+	t.length++
+}
+
+/*
+Returns all values of key k in insertion order. The returned slice is a copy
+*/
+func (t *MultiBTree[K, V]) GetAll(k K) []V {
+	values, _ := t.tree.Get(k)
+	return slices.Clone(values)
+}
+
+/*
+Returns the number of values stored under key k
+*/
+func (t *MultiBTree[K, V]) Count(k K) int {
+	values, _ := t.tree.Get(k)
+	return len(values)
+}
+
+/*
+Delete the first value of key k for which pred returns true. Returns whether a
+value was deleted
+*/
+func (t *MultiBTree[K, V]) DeleteOne(k K, pred func(V) bool) bool {
+	values, found := t.tree.Get(k)
+	if !found {
+		return false
+	}
+	idx := slices.IndexFunc(values, pred)
+	if idx == -1 {
+		return false
+	}
+
+	values = slices.Delete(values, idx, idx+1)
+	if len(values) == 0 {
+		t.tree.Delete(k)
+	} else {
+		t.tree.Insert(k, values)
+	}
+	t.length--
+	return true
+}
+
+/*
+Delete all values of key k. Returns the number of values deleted
+*/
+func (t *MultiBTree[K, V]) DeleteAll(k K) int {
+	values, found := t.tree.Get(k)
+	if !found {
+		return 0
+	}
+	t.tree.Delete(k)
+	t.length -= len(values)
+	return len(values)
+}
+
+/*
+Returns the total number of values in the tree
+*/
+func (t *MultiBTree[K, V]) Len() int {
+	return t.length
+}
+
+/*
+Returns an iterator over all key, value pairs in ascending key order. Values of
+the same key are yielded in insertion order
+*/
+func (t *MultiBTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, values := range t.tree.All() {
+			for _, v := range values {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
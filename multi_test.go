@@ -0,0 +1,103 @@
+package btree
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestMultiGetAll(t *testing.T) {
+	for d := 2; d < 6; d++ {
+		t.Run(fmt.Sprintf("GetAll at degree %v", d), func(t *testing.T) {
+			tree := NewMultiBtree[string, int](d)
+			for i := range 30 {
+				tree.Insert(fmt.Sprintf("country-%v", i%3), i)
+			}
+
+			tests := []struct {
+				key      string
+				expected []int
+			}{
+				{"country-0", []int{0, 3, 6, 9, 12, 15, 18, 21, 24, 27}},
+				{"country-2", []int{2, 5, 8, 11, 14, 17, 20, 23, 26, 29}},
+				{"country-3", nil},
+			}
+			for _, test := range tests {
+				if values := tree.GetAll(test.key); !slices.Equal(values, test.expected) {
+					t.Errorf("GetAll(%v) = %v; expected %v", test.key, values, test.expected)
+				}
+				if count := tree.Count(test.key); count != len(test.expected) {
+					t.Errorf("Count(%v) = %v; expected %v", test.key, count, len(test.expected))
+				}
+			}
+			if tree.Len() != 30 {
+				t.Errorf("Len() = %v; expected 30", tree.Len())
+			}
+		})
+	}
+}
+
+func TestMultiDeleteOne(t *testing.T) {
+	tree := NewMultiBtree[int, string](2)
+	tree.Insert(1, "a")
+	tree.Insert(1, "b")
+	tree.Insert(1, "a")
+	tree.Insert(2, "c")
+
+	isA := func(v string) bool { return v == "a" }
+
+	if !tree.DeleteOne(1, isA) {
+		t.Errorf("DeleteOne(1, a) found nothing")
+	}
+	if values := tree.GetAll(1); !slices.Equal(values, []string{"b", "a"}) {
+		t.Errorf("GetAll(1) = %v; expected [b a]", values)
+	}
+	if tree.DeleteOne(2, isA) {
+		t.Errorf("DeleteOne(2, a) deleted a non-matching value")
+	}
+	if tree.DeleteOne(3, isA) {
+		t.Errorf("DeleteOne(3, a) deleted from a missing key")
+	}
+
+	tree.DeleteOne(2, func(string) bool { return true })
+	if tree.Count(2) != 0 {
+		t.Errorf("Key 2 still present after deleting its only value")
+	}
+	if tree.Len() != 2 {
+		t.Errorf("Len() = %v; expected 2", tree.Len())
+	}
+}
+
+func TestMultiDeleteAll(t *testing.T) {
+	tree := NewMultiBtree[int, int](3)
+	for i := range 10 {
+		tree.Insert(i%2, i)
+	}
+
+	if n := tree.DeleteAll(0); n != 5 {
+		t.Errorf("DeleteAll(0) = %v; expected 5", n)
+	}
+	if n := tree.DeleteAll(0); n != 0 {
+		t.Errorf("DeleteAll(0) = %v; expected 0", n)
+	}
+
+	var pairs []string
+	for k, v := range tree.All() {
+		pairs = append(pairs, fmt.Sprintf("%v:%v", k, v))
+	}
+	expected := []string{"1:1", "1:3", "1:5", "1:7", "1:9"}
+	if !slices.Equal(pairs, expected) {
+		t.Errorf("All() = %v; expected %v", pairs, expected)
+	}
+}
+
+func TestMultiGetAllIsCopy(t *testing.T) {
+	tree := NewMultiBtree[int, int](2)
+	tree.Insert(1, 1)
+	values := tree.GetAll(1)
+	values[0] = 100
+
+	if values := tree.GetAll(1); values[0] != 1 {
+		t.Errorf("Modifying GetAll result changed the tree: %v", values)
+	}
+}
@@ -0,0 +1,137 @@
+package btree
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// Target node size used by New when neither WithDegree nor WithTargetNodeBytes is given
+const DefaultTargetNodeBytes = 256
+
+// ErrInvalidConfig is wrapped by every error New returns for a bad option
+var ErrInvalidConfig = errors.New("btree: invalid configuration")
+
+// Option configures a btree created by New
+type Option func(*config) error
+
+type config struct {
+	degree          int
+	targetNodeBytes int
+	// A func(a, b K) int and a *FreeList[K, V], checked against K and V by New
+	comparator any
+	allocator  any
+}
+
+/*
+Use a fixed degree. Nodes hold between degree-1 and 2*degree-1 items
+*/
+func WithDegree(degree int) Option {
+	return func(c *config) error {
+		if degree < 2 {
+			return fmt.Errorf("%w: degree %d must be larger than 1", ErrInvalidConfig, degree)
+		}
+		c.degree = degree
+		return nil
+	}
+}
+
+/*
+Pick the degree so that the items of a full node take up about the given number of
+bytes. The size of an item is taken from unsafe.Sizeof, so memory referenced by keys
+and values, like string contents, is not counted
+*/
+func WithTargetNodeBytes(bytes int) Option {
+	return func(c *config) error {
+		if bytes <= 0 {
+			return fmt.Errorf("%w: target node bytes %d must be positive", ErrInvalidConfig, bytes)
+		}
+		c.targetNodeBytes = bytes
+		return nil
+	}
+}
+
+/*
+Order keys with compare instead of their natural ordering. compare must return a
+negative number, zero or a positive number when a is less than, equal to or greater than b
+*/
+func WithComparator[K cmp.Ordered](compare func(a, b K) int) Option {
+	return func(c *config) error {
+		if compare == nil {
+			return fmt.Errorf("%w: comparator must not be nil", ErrInvalidConfig)
+		}
+		c.comparator = compare
+		return nil
+	}
+}
+
+/*
+Take nodes from and return nodes to the free list f
+*/
+func WithAllocator[K cmp.Ordered, V any](f *FreeList[K, V]) Option {
+	return func(c *config) error {
+		if f == nil {
+			return fmt.Errorf("%w: allocator must not be nil", ErrInvalidConfig)
+		}
+		c.allocator = f
+		return nil
+	}
+}
+
+/*
+Creates an empty btree configured by opts. Without a degree option, the degree is
+picked for nodes of DefaultTargetNodeBytes. Returns an error wrapping ErrInvalidConfig
+if the options are invalid or conflict
+*/
+func New[K cmp.Ordered, V any](opts ...Option) (*BTree[K, V], error) {
+	var c config
+	for _, opt := range opts {
+		if err := opt(&c); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.degree != 0 && c.targetNodeBytes != 0 {
+		return nil, fmt.Errorf("%w: WithDegree and WithTargetNodeBytes are mutually exclusive", ErrInvalidConfig)
+	}
+	degree := c.degree
+	if degree == 0 {
+		targetNodeBytes := c.targetNodeBytes
+		if targetNodeBytes == 0 {
+			targetNodeBytes = DefaultTargetNodeBytes
+		}
+		degree = degreeForNodeBytes[K, V](targetNodeBytes)
+	}
+	t := NewBtree[K, V](degree)
+
+	if c.comparator != nil {
+		compare, ok := c.comparator.(func(a, b K) int)
+		if !ok {
+			return nil, fmt.Errorf("%w: comparator %T does not compare keys of type %T", ErrInvalidConfig, c.comparator, *new(K))
+		}
+		t.compare = compare
+	}
+	if c.allocator != nil {
+		freeList, ok := c.allocator.(*FreeList[K, V])
+		if !ok {
+			return nil, fmt.Errorf("%w: allocator %T does not hold nodes of %T", ErrInvalidConfig, c.allocator, t)
+		}
+		t.freeList = freeList
+	}
+
+	return t, nil
+}
+
+/*
+Returns the degree whose full nodes hold about bytes worth of items, and at least 2
+*/
+func degreeForNodeBytes[K cmp.Ordered, V any](bytes int) int {
+	itemSize := int(unsafe.Sizeof(Item[K, V]{}))
+	// Zero-size items, like in sets of struct{} keys, still cost a slot
+	itemSize = max(itemSize, 1)
+
+	// A full node holds 2*degree-1 items
+	maxItems := bytes / itemSize
+	return max((maxItems+1)/2, 2)
+}
@@ -0,0 +1,138 @@
+package btree
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestNewDefaults(t *testing.T) {
+	btree, err := New[int, int]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Items of two ints take 16 bytes, so 256 bytes fit 16 of them
+	if btree.degree != 8 {
+		t.Errorf("Default degree = %v; expected 8", btree.degree)
+	}
+}
+
+func TestNewDegreeOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected int
+	}{
+		{"degree", []Option{WithDegree(5)}, 5},
+		{"target bytes", []Option{WithTargetNodeBytes(1024)}, 32},
+		{"tiny target bytes", []Option{WithTargetNodeBytes(1)}, 2},
+	}
+
+	for _, test := range tests {
+		btree, err := New[int64, int64](test.opts...)
+		if err != nil {
+			t.Errorf("%v: New() error = %v", test.name, err)
+			continue
+		}
+		if btree.degree != test.expected {
+			t.Errorf("%v: degree = %v; expected %v", test.name, btree.degree, test.expected)
+		}
+	}
+}
+
+func TestNewLargeValuesGetSmallDegree(t *testing.T) {
+	btree, err := New[int, [1024]byte](WithTargetNodeBytes(4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if btree.degree != 2 {
+		t.Errorf("degree = %v; expected 2", btree.degree)
+	}
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"small degree", []Option{WithDegree(1)}},
+		{"non-positive target bytes", []Option{WithTargetNodeBytes(0)}},
+		{"conflicting degree options", []Option{WithDegree(4), WithTargetNodeBytes(512)}},
+		{"nil comparator", []Option{WithComparator[int](nil)}},
+		{"comparator of other key type", []Option{WithComparator(cmp.Compare[string])}},
+		{"nil allocator", []Option{WithAllocator[int, int](nil)}},
+		{"allocator of other value type", []Option{WithAllocator(NewFreeList[int, string](4))}},
+	}
+
+	for _, test := range tests {
+		if _, err := New[int, int](test.opts...); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%v: New() error = %v; expected ErrInvalidConfig", test.name, err)
+		}
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	random := rand.New(rand.NewPCG(424242, 1024))
+	descending := func(a, b int) int {
+		return cmp.Compare(b, a)
+	}
+
+	for d := 2; d < 6; d++ {
+		t.Run(fmt.Sprintf("Descending at degree %v", d), func(t *testing.T) {
+			btree, err := New[int, int](WithDegree(d), WithComparator(descending))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := make(map[int]int)
+			for i := range 2000 {
+				key := random.IntN(200)
+				if random.IntN(3) == 0 {
+					btree.Delete(key)
+					delete(expected, key)
+				} else {
+					btree.Insert(key, i)
+					expected[key] = i
+				}
+				if err := btree.Validate(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for k, v := range expected {
+				if got, found := btree.Get(k); !found || got != v {
+					t.Errorf("Get(%d) = (%v, %v); expected (%v, true)", k, got, found, v)
+				}
+			}
+
+			var keys []int
+			for k := range btree.All() {
+				keys = append(keys, k)
+			}
+			if !slices.IsSortedFunc(keys, descending) || len(keys) != len(expected) {
+				t.Errorf("All() = %v; expected %v keys in descending order", keys, len(expected))
+			}
+		})
+	}
+}
+
+func TestNewWithAllocator(t *testing.T) {
+	freeList := NewFreeList[int, int](16)
+	btree, err := New[int, int](WithDegree(2), WithAllocator(freeList))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 100 {
+		btree.Insert(i, i)
+	}
+	for i := range 100 {
+		btree.Delete(i)
+	}
+	if freeList.Len() == 0 {
+		t.Errorf("Tree did not return nodes to its allocator")
+	}
+}
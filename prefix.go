@@ -0,0 +1,467 @@
+package btree
+
+import (
+	"iter"
+	"slices"
+	"strings"
+)
+
+/*
+PrefixBTree is a B+tree of string keys which stores the prefix shared by all keys
+of a node once, and only the remaining suffix of each key. Items live in the
+leaves. Internal nodes hold separators, which are truncated to the shortest
+string that still separates the two leaves they were split from.
+
+Suited to long keys with shared prefixes, such as paths. Byte slice keys can be
+stored by converting them to strings, which preserves bytes.Compare ordering
+*/
+type PrefixBTree[V any] struct {
+	degree int
+	root   *prefixNode[V]
+	length int
+}
+
+/*
+Every key, or separator, of a node is prefix + suffixes[i]. prefix is a common
+prefix of the node's keys, but not necessarily the longest one, since it is only
+extended again by compact
+*/
+type prefixNode[V any] struct {
+	prefix   string
+	suffixes []string
+	// Values of the keys, for leaves only
+	values []V
+	// Children of internal nodes. Keys in children[i] are below separator i, and
+	// keys in children[i+1] are at or above it
+	children []*prefixNode[V]
+}
+
+func NewPrefixBtree[V any](degree int) *PrefixBTree[V] {
+	if degree < 2 {
+		panic("Invalid degree. Must be larger than 1")
+	}
+	return &PrefixBTree[V]{degree: degree}
+}
+
+func (t *PrefixBTree[V]) minItems() int {
+	return t.degree - 1
+}
+
+func (t *PrefixBTree[V]) maxItems() int {
+	return t.degree*2 - 1
+}
+
+func (t *PrefixBTree[V]) newLeaf() *prefixNode[V] {
+	return &prefixNode[V]{
+		suffixes: make([]string, 0, t.maxItems()),
+		values:   make([]V, 0, t.maxItems()),
+	}
+}
+
+func (t *PrefixBTree[V]) newInternal() *prefixNode[V] {
+	return &prefixNode[V]{
+		suffixes: make([]string, 0, t.maxItems()),
+		children: make([]*prefixNode[V], 0, t.degree*2),
+	}
+}
+
+func (n *prefixNode[V]) isLeaf() bool {
+	return n.children == nil
+}
+
+// Implements nodeOps. The items of internal nodes are their separators
+func (t *PrefixBTree[V]) nodeSize(n *prefixNode[V]) int {
+	return len(n.suffixes)
+}
+
+func (t *PrefixBTree[V]) nodeIsLeaf(n *prefixNode[V]) bool {
+	return n.isLeaf()
+}
+
+func (t *PrefixBTree[V]) nodeChild(n *prefixNode[V], i int) *prefixNode[V] {
+	return n.children[i]
+}
+
+func (n *prefixNode[V]) key(i int) string {
+	return n.prefix + n.suffixes[i]
+}
+
+/*
+Returns the index of the first key of n not below k, and whether it equals k. Takes
+the place of items.find: k is compared against the node prefix once, and only its
+remainder against the suffixes
+*/
+func (n *prefixNode[V]) find(k string) (int, bool) {
+	if !strings.HasPrefix(k, n.prefix) {
+		// k sorts before or after every key of the node, depending on where it
+		// diverges from the prefix
+		common := commonPrefixLen(k, n.prefix)
+		if common == len(k) || k[common] < n.prefix[common] {
+			return 0, false
+		}
+		return len(n.suffixes), false
+	}
+	return slices.BinarySearch(n.suffixes, k[len(n.prefix):])
+}
+
+/*
+Returns the index of the child of internal node n whose range contains k
+*/
+func (n *prefixNode[V]) childIndex(k string) int {
+	idx, found := n.find(k)
+	if found {
+		return idx + 1
+	}
+	return idx
+}
+
+/*
+Inserts key k at index idx, shortening the node prefix if k does not share it.
+Suffixes are copied so that they do not keep the full key alive
+*/
+func (n *prefixNode[V]) insertKey(idx int, k string) {
+	if !strings.HasPrefix(k, n.prefix) {
+		n.shortenPrefix(commonPrefixLen(k, n.prefix))
+	}
+	n.suffixes = slices.Insert(n.suffixes, idx, strings.Clone(k[len(n.prefix):]))
+}
+
+// Removes the key at index idx, and extends the prefix if the remaining keys allow it
+func (n *prefixNode[V]) removeKey(idx int) {
+	n.suffixes = slices.Delete(n.suffixes, idx, idx+1)
+	n.compact()
+}
+
+// Moves all but the first length bytes of the prefix into the suffixes
+func (n *prefixNode[V]) shortenPrefix(length int) {
+	moved := n.prefix[length:]
+	for i, s := range n.suffixes {
+		n.suffixes[i] = moved + s
+	}
+	n.prefix = n.prefix[:length]
+}
+
+/*
+Extends the prefix to the longest prefix of all keys. As the suffixes are sorted,
+that is the common prefix of the first and last suffix
+*/
+func (n *prefixNode[V]) compact() {
+	if len(n.suffixes) == 0 {
+		return
+	}
+	first, last := n.suffixes[0], n.suffixes[len(n.suffixes)-1]
+	extra := commonPrefixLen(first, last)
+	if extra == 0 {
+		return
+	}
+	n.prefix = n.prefix + first[:extra]
+	for i, s := range n.suffixes {
+		n.suffixes[i] = strings.Clone(s[extra:])
+	}
+}
+
+func commonPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+/*
+Returns the shortest string s with left < s <= right, for left < right
+*/
+func shortestSeparator(left, right string) string {
+	return right[:commonPrefixLen(left, right)+1]
+}
+
+/*
+Returns the number of items in the btree
+*/
+func (t *PrefixBTree[V]) Len() int {
+	return t.length
+}
+
+/*
+Attempt to get item with key k. Success is indicated by returned bool
+*/
+func (t *PrefixBTree[V]) Get(k string) (V, bool) {
+	if t.root != nil {
+		n := t.root
+		for !n.isLeaf() {
+			n = n.children[n.childIndex(k)]
+		}
+		if idx, found := n.find(k); found {
+			return n.values[idx], true
+		}
+	}
+	var zeroVal V
+	return zeroVal, false
+}
+
+/*
+Returns an iterator over all key, value pairs in ascending key order.
+The tree must not be modified while iterating
+*/
+func (t *PrefixBTree[V]) All() iter.Seq2[string, V] {
+	return t.AscendFrom("")
+}
+
+/*
+Returns an iterator over all key, value pairs with keys greater than or equal to
+pivot, in ascending key order. The tree must not be modified while iterating
+*/
+func (t *PrefixBTree[V]) AscendFrom(pivot string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		if t.root != nil {
+			t.ascendFrom(t.root, pivot, yield)
+		}
+	}
+}
+
+func (t *PrefixBTree[V]) ascendFrom(n *prefixNode[V], pivot string, yield func(string, V) bool) bool {
+	if n.isLeaf() {
+		idx, _ := n.find(pivot)
+		for i := idx; i < len(n.suffixes); i++ {
+			if !yield(n.key(i), n.values[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for i := n.childIndex(pivot); i < len(n.children); i++ {
+		if !t.ascendFrom(n.children[i], pivot, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Returns the number of bytes of keys and separators stored in the tree, counting
+each node prefix once
+*/
+func (t *PrefixBTree[V]) KeyBytes() int {
+	if t.root == nil {
+		return 0
+	}
+	return t.root.keyBytes()
+}
+
+func (n *prefixNode[V]) keyBytes() int {
+	total := len(n.prefix)
+	for _, s := range n.suffixes {
+		total += len(s)
+	}
+	for _, child := range n.children {
+		total += child.keyBytes()
+	}
+	return total
+}
+
+/*
+Splits a full node n. Returns the separator for the parent, and the new right node
+*/
+func (t *PrefixBTree[V]) split(n *prefixNode[V]) (string, *prefixNode[V]) {
+	median := len(n.suffixes) / 2
+
+	var separator string
+	var right *prefixNode[V]
+	if n.isLeaf() {
+		right = t.newLeaf()
+		separator = shortestSeparator(n.key(median-1), n.key(median))
+		right.suffixes, n.suffixes = moveTail(right.suffixes, n.suffixes, median)
+		right.values, n.values = moveTail(right.values, n.values, median)
+	} else {
+		// The median separator moves up, and the children split around it
+		right = t.newInternal()
+		separator = n.key(median)
+		right.suffixes, n.suffixes = moveTail(right.suffixes, n.suffixes, median+1)
+		right.children, n.children = moveTail(right.children, n.children, median+1)
+		n.suffixes = slices.Delete(n.suffixes, median, median+1)
+	}
+	right.prefix = n.prefix
+
+	n.compact()
+	right.compact()
+	return separator, right
+}
+
+/*
+Insert key,value pair into btree
+*/
+func (t *PrefixBTree[V]) Insert(k string, v V) {
+	// Initialize btree if required
+	if t.root == nil {
+		t.root = t.newLeaf()
+		t.root.insertKey(0, k)
+		t.root.values = append(t.root.values, v)
+		t.root.compact()
+		t.length = 1
+		return
+	}
+	if len(t.root.suffixes) >= t.maxItems() {
+		separator, right := t.split(t.root)
+		newRoot := t.newInternal()
+		newRoot.insertKey(0, separator)
+		newRoot.compact()
+		newRoot.children = append(newRoot.children, t.root, right)
+		t.root = newRoot
+	}
+
+	if t.insert(k, v, t.root) {
+		t.length++
+	}
+}
+
+/*
+Insert key, value pair into subtree rooted at n, which is not full. Returns whether
+a new item was added, as opposed to an existing one being replaced
+*/
+func (t *PrefixBTree[V]) insert(k string, v V, n *prefixNode[V]) bool {
+	if n.isLeaf() {
+		idx, found := n.find(k)
+		if found {
+			n.values[idx] = v
+			return false
+		}
+		n.insertKey(idx, k)
+		n.values = slices.Insert(n.values, idx, v)
+		return true
+	}
+
+	idx := n.childIndex(k)
+	if len(n.children[idx].suffixes) >= t.maxItems() {
+		separator, right := t.split(n.children[idx])
+		n.insertKey(idx, separator)
+		n.children = slices.Insert(n.children, idx+1, right)
+
+		// The split might change our direction
+		if k >= separator {
+			idx++
+		}
+	}
+	return t.insert(k, v, n.children[idx])
+}
+
+/*
+Delete item with key k from btree. Returns whether the key was found
+*/
+func (t *PrefixBTree[V]) Delete(k string) bool {
+	if t.root == nil {
+		return false
+	}
+
+	found := t.delete(k, t.root)
+	t.root, _ = shrinkRoot(t, t.root)
+
+	if found {
+		t.length--
+	}
+	return found
+}
+
+/*
+Delete item with key k from subtree rooted at n, which has more than the minimum
+number of keys unless it is the root. Returns whether key was found
+*/
+func (t *PrefixBTree[V]) delete(k string, n *prefixNode[V]) bool {
+	if n.isLeaf() {
+		idx, found := n.find(k)
+		if found {
+			n.values = slices.Delete(n.values, idx, idx+1)
+			n.removeKey(idx)
+		}
+		return found
+	}
+
+	return t.delete(k, deletePathChild(t, n, n.childIndex(k)))
+}
+
+/*
+Rebalances child at index i of node n. Returns a pointer to child i
+or its left sibling, if child i got merged into it
+*/
+func (t *PrefixBTree[V]) rebalance(n *prefixNode[V], i int) *prefixNode[V] {
+	return rebalanceChild(t, n, i)
+}
+
+// Replaces separator i of internal node n
+func (n *prefixNode[V]) replaceKey(i int, k string) {
+	n.removeKey(i)
+	n.insertKey(i, k)
+}
+
+/*
+Moves the last key of the left sibling of child i into it. For leaves the
+separator between them is recomputed, for internal nodes it rotates through n
+*/
+func (t *PrefixBTree[V]) stealFromLeftSibling(n *prefixNode[V], i int) {
+	child, sibling := n.children[i], n.children[i-1]
+	last := len(sibling.suffixes) - 1
+
+	if child.isLeaf() {
+		child.insertKey(0, sibling.key(last))
+		child.values = slices.Insert(child.values, 0, sibling.values[last])
+		var zeroVal V
+		sibling.values[last] = zeroVal
+		sibling.values = sibling.values[:last]
+		sibling.removeKey(last)
+		n.replaceKey(i-1, shortestSeparator(sibling.key(last-1), child.key(0)))
+		return
+	}
+
+	child.insertKey(0, n.key(i-1))
+	child.children = slices.Insert(child.children, 0, sibling.children[last+1])
+	n.replaceKey(i-1, sibling.key(last))
+	sibling.children[last+1] = nil
+	sibling.children = sibling.children[:last+1]
+	sibling.removeKey(last)
+}
+
+/*
+Moves the first key of the right sibling of child i into it. For leaves the
+separator between them is recomputed, for internal nodes it rotates through n
+*/
+func (t *PrefixBTree[V]) stealFromRightSibling(n *prefixNode[V], i int) {
+	child, sibling := n.children[i], n.children[i+1]
+
+	if child.isLeaf() {
+		child.insertKey(len(child.suffixes), sibling.key(0))
+		child.values = append(child.values, sibling.values[0])
+		sibling.values = slices.Delete(sibling.values, 0, 1)
+		sibling.removeKey(0)
+		n.replaceKey(i, shortestSeparator(child.key(len(child.suffixes)-1), sibling.key(0)))
+		return
+	}
+
+	child.insertKey(len(child.suffixes), n.key(i))
+	child.children = append(child.children, sibling.children[0])
+	n.replaceKey(i, sibling.key(0))
+	sibling.children = slices.Delete(sibling.children, 0, 1)
+	sibling.removeKey(0)
+}
+
+/*
+Merge child at index i of node n, with child at index i+1. Leaves are concatenated,
+internal nodes take the separator between them from n
+*/
+func (t *PrefixBTree[V]) merge(n *prefixNode[V], i int) {
+	child, sibling := n.children[i], n.children[i+1]
+
+	if !child.isLeaf() {
+		child.insertKey(len(child.suffixes), n.key(i))
+	}
+	for j := range sibling.suffixes {
+		child.insertKey(len(child.suffixes), sibling.key(j))
+	}
+	child.values = append(child.values, sibling.values...)
+	child.children = append(child.children, sibling.children...)
+	child.compact()
+
+	n.children = slices.Delete(n.children, i+1, i+2)
+	n.removeKey(i)
+}
@@ -0,0 +1,276 @@
+package btree
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+/*
+Checks the invariants of a PrefixBTree: keys and separators are strictly ascending
+within and across nodes, separators bound their children, nodes are within fill
+bounds and all leaves are at the same depth. Returns the keys in order
+*/
+func checkPrefixTree[V any](t *testing.T, tree *PrefixBTree[V]) []string {
+	t.Helper()
+	if tree.root == nil {
+		if tree.length != 0 {
+			t.Fatalf("empty tree has length %v", tree.length)
+		}
+		return nil
+	}
+
+	var keys []string
+	leafDepth := -1
+	var walk func(n *prefixNode[V], depth int, lower, upper *string)
+	walk = func(n *prefixNode[V], depth int, lower, upper *string) {
+		if n != tree.root && (len(n.suffixes) < tree.minItems() || len(n.suffixes) > tree.maxItems()) {
+			t.Fatalf("node with prefix %q has %v keys; expected between %v and %v", n.prefix, len(n.suffixes), tree.minItems(), tree.maxItems())
+		}
+		for i := range n.suffixes {
+			k := n.key(i)
+			if i > 0 && n.key(i-1) >= k {
+				t.Fatalf("keys %q and %q are out of order", n.key(i-1), k)
+			}
+			if (lower != nil && k < *lower) || (upper != nil && k >= *upper) {
+				t.Fatalf("key %q is outside the bounds of its separators", k)
+			}
+		}
+
+		if n.isLeaf() {
+			if len(n.values) != len(n.suffixes) {
+				t.Fatalf("leaf has %v keys and %v values", len(n.suffixes), len(n.values))
+			}
+			if leafDepth >= 0 && depth != leafDepth {
+				t.Fatalf("leaves at depths %v and %v", leafDepth, depth)
+			}
+			leafDepth = depth
+			for i := range n.suffixes {
+				keys = append(keys, n.key(i))
+			}
+			return
+		}
+
+		if len(n.children) != len(n.suffixes)+1 || n.values != nil {
+			t.Fatalf("internal node has %v separators, %v children and %v values", len(n.suffixes), len(n.children), len(n.values))
+		}
+		for i, child := range n.children {
+			childLower, childUpper := lower, upper
+			if i > 0 {
+				sep := n.key(i - 1)
+				childLower = &sep
+			}
+			if i < len(n.suffixes) {
+				sep := n.key(i)
+				childUpper = &sep
+			}
+			walk(child, depth+1, childLower, childUpper)
+		}
+	}
+	walk(tree.root, 0, nil, nil)
+
+	if len(keys) != tree.Len() {
+		t.Fatalf("tree holds %v keys but Len() = %v", len(keys), tree.Len())
+	}
+	return keys
+}
+
+func TestPrefixNodeFind(t *testing.T) {
+	n := &prefixNode[int]{prefix: "tenant/1/", suffixes: []string{"b", "d", "f"}}
+	tests := []struct {
+		k     string
+		idx   int
+		found bool
+	}{
+		{"tenant/1/a", 0, false},
+		{"tenant/1/b", 0, true},
+		{"tenant/1/c", 1, false},
+		{"tenant/1/f", 2, true},
+		{"tenant/1/g", 3, false},
+		{"tenant/1/", 0, false},
+		{"tenant/", 0, false},
+		{"", 0, false},
+		{"tenant/0/z", 0, false},
+		{"tenant/2", 3, false},
+		{"u", 3, false},
+	}
+
+	for _, test := range tests {
+		idx, found := n.find(test.k)
+		if idx != test.idx || found != test.found {
+			t.Errorf("find(%q) = (%v, %v); expected (%v, %v)", test.k, idx, found, test.idx, test.found)
+		}
+	}
+}
+
+func TestShortestSeparator(t *testing.T) {
+	tests := []struct {
+		left, right, expected string
+	}{
+		{"abc", "abd", "abd"},
+		{"abc", "abzzz", "abz"},
+		{"a/b/c/1", "a/b/c/2/x", "a/b/c/2"},
+		{"ab", "abc", "abc"},
+		{"", "a", "a"},
+	}
+	for _, test := range tests {
+		got := shortestSeparator(test.left, test.right)
+		if got != test.expected || got <= test.left || got > test.right {
+			t.Errorf("shortestSeparator(%q, %q) = %q; expected %q", test.left, test.right, got, test.expected)
+		}
+	}
+}
+
+func pathKey(i int) string {
+	return fmt.Sprintf("/srv/data/tenants/acme-corporation/projects/infrastructure/objects/%010d", i)
+}
+
+func TestPrefixBTreeOperations(t *testing.T) {
+	for _, degree := range []int{2, 3, 5, 16} {
+		t.Run(fmt.Sprintf("degree %v", degree), func(t *testing.T) {
+			tree := NewPrefixBtree[int](degree)
+			model := make(map[string]int)
+			r := rand.New(rand.NewSource(int64(degree)))
+
+			randomKey := func() string {
+				switch r.Intn(3) {
+				case 0:
+					return pathKey(r.Intn(500))
+				case 1:
+					return fmt.Sprintf("/srv/data/tenants/%v/%v", r.Intn(10), r.Intn(50))
+				default:
+					return strings.Repeat("a", r.Intn(5)) + string(rune('a'+r.Intn(3)))
+				}
+			}
+
+			for i := range 5000 {
+				k := randomKey()
+				if r.Intn(3) == 0 {
+					_, inModel := model[k]
+					if deleted := tree.Delete(k); deleted != inModel {
+						t.Fatalf("Delete(%q) = %v; expected %v", k, deleted, inModel)
+					}
+					delete(model, k)
+				} else {
+					tree.Insert(k, i)
+					model[k] = i
+				}
+				if i%250 == 0 {
+					checkPrefixTree(t, tree)
+				}
+			}
+
+			keys := checkPrefixTree(t, tree)
+			expected := make([]string, 0, len(model))
+			for k := range model {
+				expected = append(expected, k)
+			}
+			slices.Sort(expected)
+			if !slices.Equal(keys, expected) {
+				t.Fatalf("tree keys differ from the model")
+			}
+			for k, v := range model {
+				if got, found := tree.Get(k); !found || got != v {
+					t.Fatalf("Get(%q) = (%v, %v); expected (%v, true)", k, got, found, v)
+				}
+			}
+			for range 100 {
+				k := randomKey() + "~"
+				if _, found := tree.Get(k); found {
+					t.Fatalf("Get(%q) found a key which was never inserted", k)
+				}
+			}
+
+			var all []string
+			for k, v := range tree.All() {
+				if model[k] != v {
+					t.Fatalf("All() yielded %q = %v; expected %v", k, v, model[k])
+				}
+				all = append(all, k)
+			}
+			if !slices.Equal(all, expected) {
+				t.Errorf("All() yielded keys out of order")
+			}
+
+			// Empty the tree completely
+			for _, k := range expected {
+				if !tree.Delete(k) {
+					t.Fatalf("Delete(%q) did not find the key", k)
+				}
+			}
+			checkPrefixTree(t, tree)
+			if tree.root != nil || tree.Len() != 0 || tree.KeyBytes() != 0 {
+				t.Errorf("tree is not empty after deleting every key")
+			}
+		})
+	}
+}
+
+func TestPrefixBTreeAscendFrom(t *testing.T) {
+	tree := NewPrefixBtree[int](2)
+	for i := range 100 {
+		tree.Insert(pathKey(i*2), i*2)
+	}
+
+	tests := []struct {
+		pivot string
+		first int
+		count int
+	}{
+		{"", 0, 100},
+		{pathKey(0), 0, 100},
+		{pathKey(1), 2, 99},
+		{pathKey(100), 100, 50},
+		{pathKey(198), 198, 1},
+		{pathKey(199), 0, 0},
+		{"/srv/data/tenants/acme", 0, 100},
+		{"/srv/data/tenants/b", 0, 0},
+	}
+
+	for _, test := range tests {
+		count := 0
+		for k, v := range tree.AscendFrom(test.pivot) {
+			if count == 0 && v != test.first {
+				t.Errorf("AscendFrom(%q) started at %q; expected %q", test.pivot, k, pathKey(test.first))
+			}
+			count++
+		}
+		if count != test.count {
+			t.Errorf("AscendFrom(%q) yielded %v items; expected %v", test.pivot, count, test.count)
+		}
+	}
+}
+
+func TestPrefixBTreeCompression(t *testing.T) {
+	tree := NewPrefixBtree[int](16)
+	fullBytes := 0
+	for i := range 10_000 {
+		k := pathKey(i)
+		tree.Insert(k, i)
+		fullBytes += len(k)
+	}
+	checkPrefixTree(t, tree)
+
+	// Keys are 80 bytes and differ in the last 10. Each should cost a few bytes
+	if got := tree.KeyBytes(); got*5 > fullBytes {
+		t.Errorf("KeyBytes() = %v; expected under a fifth of the %v bytes of full keys", got, fullBytes)
+	}
+
+	// Separators are truncated to the shortest distinguishing prefix
+	for _, s := range tree.root.suffixes {
+		if len(s) > len("0000000000") {
+			t.Errorf("root separator suffix %q is longer than the distinguishing part of a key", s)
+		}
+	}
+}
+
+func TestPrefixBTreeInvalidDegree(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewPrefixBtree(1) did not panic")
+		}
+	}()
+	NewPrefixBtree[int](1)
+}
@@ -0,0 +1,106 @@
+package btree
+
+/*
+nodeOps gives the rebalancing logic shared by SoABTree and PrefixBTree access to
+their nodes of type N. Internal nodes with n items, or separators, have n+1 children,
+and child i holds the keys between items i-1 and i
+*/
+type nodeOps[N any] interface {
+	minItems() int
+	// Number of items, or separators, of n
+	nodeSize(n N) int
+	nodeIsLeaf(n N) bool
+	nodeChild(n N, i int) N
+	// Rebalances child i of n, which has the minimum number of items. Usually rebalanceChild
+	rebalance(n N, i int) N
+	// Moves an item from child i-1 of n into child i, through n
+	stealFromLeftSibling(n N, i int)
+	// Moves an item from child i+1 of n into child i, through n
+	stealFromRightSibling(n N, i int)
+	// Merges child i+1 of n into child i, along with the item between them
+	merge(n N, i int)
+}
+
+/*
+Rebalances child at index i of node n, which has the minimum number of items, by
+stealing an item from a sibling which can spare one, and merging with a sibling
+otherwise. Returns child i, or its left sibling if child i got merged into it
+*/
+func rebalanceChild[N any](ops nodeOps[N], n N, i int) N {
+	hasLeftSibling := i > 0
+	hasRightSibling := i < ops.nodeSize(n)
+
+	if hasLeftSibling && ops.nodeSize(ops.nodeChild(n, i-1)) > ops.minItems() {
+		ops.stealFromLeftSibling(n, i)
+	} else if hasRightSibling && ops.nodeSize(ops.nodeChild(n, i+1)) > ops.minItems() {
+		ops.stealFromRightSibling(n, i)
+	} else if hasRightSibling {
+		ops.merge(n, i)
+	} else {
+		ops.merge(n, i-1)
+		// We have merged our old target into its left sibling and must change course
+		return ops.nodeChild(n, i-1)
+	}
+	return ops.nodeChild(n, i)
+}
+
+/*
+Returns the child at index i of node n for a delete to descend into, rebalancing
+it first unless it has more than the minimum number of items, so that it can
+give one up
+*/
+func deletePathChild[N any](ops nodeOps[N], n N, i int) N {
+	if child := ops.nodeChild(n, i); ops.nodeSize(child) > ops.minItems() {
+		return child
+	}
+	return ops.rebalance(n, i)
+}
+
+/*
+Descends from n, which has more than the minimum number of items, to the leaf
+holding its largest item, rebalancing on the way so that the leaf can give it up
+*/
+func descendToMax[N any](ops nodeOps[N], n N) N {
+	for !ops.nodeIsLeaf(n) {
+		n = deletePathChild(ops, n, ops.nodeSize(n))
+	}
+	return n
+}
+
+/*
+Descends from n, which has more than the minimum number of items, to the leaf
+holding its smallest item, rebalancing on the way so that the leaf can give it up
+*/
+func descendToMin[N any](ops nodeOps[N], n N) N {
+	for !ops.nodeIsLeaf(n) {
+		n = deletePathChild(ops, n, 0)
+	}
+	return n
+}
+
+/*
+Returns the root after a delete, which replaces an empty root by its only child.
+The returned bool reports whether the root was replaced. An empty leaf root is
+replaced by the zero N
+*/
+func shrinkRoot[N any](ops nodeOps[N], root N) (N, bool) {
+	if ops.nodeSize(root) > 0 {
+		return root, false
+	}
+	var newRoot N
+	if !ops.nodeIsLeaf(root) {
+		newRoot = ops.nodeChild(root, 0)
+	}
+	return newRoot, true
+}
+
+/*
+Moves the elements of src from index i onwards to the end of dst. The moved
+elements are cleared in src, so that it does not keep them alive. Returns the
+extended dst and the truncated src
+*/
+func moveTail[S ~[]E, E any](dst, src S, i int) (S, S) {
+	dst = append(dst, src[i:]...)
+	clear(src[i:])
+	return dst, src[:i]
+}
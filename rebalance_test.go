@@ -0,0 +1,113 @@
+package btree
+
+import (
+	"slices"
+	"testing"
+)
+
+// A node of which only the number of items matters
+type sizedNode struct {
+	size     int
+	children []*sizedNode
+}
+
+// Records which operation rebalanceChild picked instead of carrying it out
+type sizedOps struct {
+	min    int
+	picked string
+	at     int
+}
+
+func (o *sizedOps) minItems() int                             { return o.min }
+func (o *sizedOps) nodeSize(n *sizedNode) int                 { return n.size }
+func (o *sizedOps) nodeIsLeaf(n *sizedNode) bool              { return len(n.children) == 0 }
+func (o *sizedOps) nodeChild(n *sizedNode, i int) *sizedNode  { return n.children[i] }
+func (o *sizedOps) rebalance(n *sizedNode, i int) *sizedNode  { return rebalanceChild(o, n, i) }
+func (o *sizedOps) stealFromLeftSibling(n *sizedNode, i int)  { o.picked, o.at = "stealLeft", i }
+func (o *sizedOps) stealFromRightSibling(n *sizedNode, i int) { o.picked, o.at = "stealRight", i }
+func (o *sizedOps) merge(n *sizedNode, i int)                 { o.picked, o.at = "merge", i }
+
+// Builds an internal node whose children hold the given numbers of items
+func newSizedNode(childSizes ...int) *sizedNode {
+	n := &sizedNode{size: len(childSizes) - 1}
+	for _, size := range childSizes {
+		n.children = append(n.children, &sizedNode{size: size})
+	}
+	return n
+}
+
+func TestRebalanceChild(t *testing.T) {
+	tests := []struct {
+		childSizes []int
+		i          int
+		picked     string
+		at         int
+		returned   int
+	}{
+		{[]int{2, 1, 2}, 1, "stealLeft", 1, 1},
+		{[]int{1, 1, 2}, 1, "stealRight", 1, 1},
+		{[]int{1, 2}, 0, "stealRight", 0, 0},
+		{[]int{2, 1}, 1, "stealLeft", 1, 1},
+		{[]int{1, 1, 1}, 1, "merge", 1, 1},
+		{[]int{1, 1}, 0, "merge", 0, 0},
+		// The last child has no right sibling, so it is merged into its left one
+		{[]int{1, 1}, 1, "merge", 0, 0},
+	}
+
+	for _, test := range tests {
+		ops := &sizedOps{min: 1}
+		n := newSizedNode(test.childSizes...)
+		returned := rebalanceChild(ops, n, test.i)
+		if ops.picked != test.picked || ops.at != test.at {
+			t.Errorf("rebalanceChild(%v, %v) picked %v(%v); expected %v(%v)", test.childSizes, test.i, ops.picked, ops.at, test.picked, test.at)
+		}
+		if returned != n.children[test.returned] {
+			t.Errorf("rebalanceChild(%v, %v) did not return child %v", test.childSizes, test.i, test.returned)
+		}
+	}
+}
+
+func TestDeletePathChild(t *testing.T) {
+	ops := &sizedOps{min: 1}
+	n := newSizedNode(2, 1)
+	if child := deletePathChild(ops, n, 0); child != n.children[0] || ops.picked != "" {
+		t.Errorf("deletePathChild() rebalanced a child which can give up an item")
+	}
+	if child := deletePathChild(ops, n, 1); child != n.children[1] || ops.picked != "stealLeft" {
+		t.Errorf("deletePathChild() picked %v; expected stealLeft", ops.picked)
+	}
+}
+
+func TestShrinkRoot(t *testing.T) {
+	ops := &sizedOps{min: 1}
+
+	full := newSizedNode(1, 1)
+	if root, shrunk := shrinkRoot(ops, full); root != full || shrunk {
+		t.Errorf("shrinkRoot() replaced a root which has items")
+	}
+	emptyInternal := &sizedNode{children: []*sizedNode{{size: 3}}}
+	if root, shrunk := shrinkRoot(ops, emptyInternal); root != emptyInternal.children[0] || !shrunk {
+		t.Errorf("shrinkRoot() did not replace an empty internal root by its child")
+	}
+	if root, shrunk := shrinkRoot(ops, &sizedNode{}); root != nil || !shrunk {
+		t.Errorf("shrinkRoot() did not replace an empty leaf root by nil")
+	}
+}
+
+func TestMoveTail(t *testing.T) {
+	dst := []*int{new(int)}
+	src := []*int{new(int), new(int), new(int)}
+	moved := slices.Clone(src[1:])
+	backing := src
+
+	dst, src = moveTail(dst, src, 1)
+	if len(dst) != 3 || !slices.Equal(dst[1:], moved) {
+		t.Errorf("moveTail() did not append the tail to dst")
+	}
+	if len(src) != 1 {
+		t.Errorf("moveTail() left %v elements in src; expected 1", len(src))
+	}
+	if backing[1] != nil || backing[2] != nil {
+		t.Errorf("moveTail() kept the moved elements in src")
+	}
+}
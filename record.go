@@ -0,0 +1,149 @@
+package btree
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	opInit   = "init"
+	opInsert = "insert"
+	opDelete = "delete"
+	opGet    = "get"
+)
+
+/*
+A single line of a trace. The first line is an init record holding the degree.
+Value is a plain V, since nil is a valid value for slices, maps, pointers and
+interfaces. It is only meaningful for insert records
+*/
+type traceRecord[K cmp.Ordered, V any] struct {
+	Op     string `json:"op"`
+	Degree int    `json:"degree,omitempty"`
+	Key    *K     `json:"k,omitempty"`
+	Value  V      `json:"v"`
+	// Result of delete and get, so replays can detect diverging behavior
+	Found *bool `json:"found,omitempty"`
+}
+
+/*
+Recorder wraps a new BTree and writes every call made through it to a trace of JSON
+lines. Keys and values must be encodable as JSON
+*/
+type Recorder[K cmp.Ordered, V any] struct {
+	tree *BTree[K, V]
+	enc  *json.Encoder
+	err  error
+}
+
+/*
+Creates an empty BTree of the given degree, recording calls to it into w
+*/
+func NewRecorder[K cmp.Ordered, V any](degree int, w io.Writer) *Recorder[K, V] {
+	r := &Recorder[K, V]{
+		tree: NewBtree[K, V](degree),
+		enc:  json.NewEncoder(w),
+	}
+	r.record(traceRecord[K, V]{Op: opInit, Degree: degree})
+	return r
+}
+
+/*
+Returns the recorded tree. Calls made directly on it are not recorded
+*/
+func (r *Recorder[K, V]) Tree() *BTree[K, V] {
+	return r.tree
+}
+
+/*
+Returns the first error encountered while writing the trace
+*/
+func (r *Recorder[K, V]) Err() error {
+	return r.err
+}
+
+func (r *Recorder[K, V]) Insert(k K, v V) {
+	r.tree.Insert(k, v)
+	r.record(traceRecord[K, V]{Op: opInsert, Key: &k, Value: v})
+}
+
+func (r *Recorder[K, V]) Delete(k K) bool {
+	found := r.tree.Delete(k)
+	r.record(traceRecord[K, V]{Op: opDelete, Key: &k, Found: &found})
+	return found
+}
+
+func (r *Recorder[K, V]) Get(k K) (V, bool) {
+	v, found := r.tree.Get(k)
+	r.record(traceRecord[K, V]{Op: opGet, Key: &k, Found: &found})
+	return v, found
+}
+
+func (r *Recorder[K, V]) record(rec traceRecord[K, V]) {
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(rec)
+}
+
+/*
+Rebuilds a tree from a trace written by a Recorder, validating it after every step.
+Returns the tree as of the last successful step, and an error if the trace is malformed,
+the tree becomes invalid, or a call returns a different result than it did when recorded
+*/
+func Replay[K cmp.Ordered, V any](trace io.Reader) (*BTree[K, V], error) {
+	dec := json.NewDecoder(trace)
+
+	var header traceRecord[K, V]
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("btree: reading trace header: %w", err)
+	}
+	if header.Op != opInit || header.Degree < 2 {
+		return nil, fmt.Errorf("btree: trace does not start with a valid init record")
+	}
+	tree := NewBtree[K, V](header.Degree)
+
+	for step := 1; ; step++ {
+		var rec traceRecord[K, V]
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return tree, nil
+		}
+		if err != nil {
+			return tree, fmt.Errorf("btree: replay step %d: %w", step, err)
+		}
+		if err := replayStep(tree, rec); err != nil {
+			return tree, fmt.Errorf("btree: replay step %d (%s): %w", step, rec.Op, err)
+		}
+		if err := tree.Validate(); err != nil {
+			return tree, fmt.Errorf("btree: replay step %d (%s): %w", step, rec.Op, err)
+		}
+	}
+}
+
+func replayStep[K cmp.Ordered, V any](tree *BTree[K, V], rec traceRecord[K, V]) error {
+	if rec.Key == nil {
+		return errors.New("record has no key")
+	}
+
+	var found bool
+	switch rec.Op {
+	case opInsert:
+		tree.Insert(*rec.Key, rec.Value)
+		return nil
+	case opDelete:
+		found = tree.Delete(*rec.Key)
+	case opGet:
+		_, found = tree.Get(*rec.Key)
+	default:
+		return fmt.Errorf("unknown operation %q", rec.Op)
+	}
+
+	if rec.Found != nil && *rec.Found != found {
+		return fmt.Errorf("key %v: found = %v, but %v when recorded", *rec.Key, found, *rec.Found)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package btree
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	random := rand.New(rand.NewPCG(424242, 1024))
+
+	var trace bytes.Buffer
+	recorder := NewRecorder[int, string](3, &trace)
+	for range 2000 {
+		key := random.IntN(200)
+		switch random.IntN(3) {
+		case 0:
+			recorder.Insert(key, strconv.Itoa(key))
+		case 1:
+			recorder.Delete(key)
+		case 2:
+			recorder.Get(key)
+		}
+	}
+	if err := recorder.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := Replay[int, string](&trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.String() != recorder.Tree().String() {
+		t.Errorf("Replayed tree differs from recorded tree:\n%v\nexpected\n%v", replayed, recorder.Tree())
+	}
+}
+
+func TestRecordFormat(t *testing.T) {
+	var trace bytes.Buffer
+	recorder := NewRecorder[string, int](2, &trace)
+	recorder.Insert("a", 1)
+	recorder.Get("a")
+	recorder.Delete("b")
+
+	expected := `{"op":"init","degree":2,"v":0}
+{"op":"insert","k":"a","v":1}
+{"op":"get","k":"a","v":0,"found":true}
+{"op":"delete","k":"b","v":0,"found":false}
+`
+	if trace.String() != expected {
+		t.Errorf("Trace =\n%v\nexpected\n%v", trace.String(), expected)
+	}
+}
+
+func TestReplayDivergence(t *testing.T) {
+	trace := `{"op":"init","degree":2}
+{"op":"insert","k":1,"v":1}
+{"op":"get","k":1,"found":true}
+{"op":"delete","k":2,"found":true}
+{"op":"insert","k":3,"v":3}
+`
+	tree, err := Replay[int, int](strings.NewReader(trace))
+	if err == nil || !strings.Contains(err.Error(), "step 3") {
+		t.Errorf("Replay() error = %v; expected a divergence at step 3", err)
+	}
+	if tree.Len() != 1 {
+		t.Errorf("Replay() stopped with %v items; expected 1", tree.Len())
+	}
+}
+
+func TestReplayMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		trace string
+	}{
+		{"empty", ""},
+		{"missing header", `{"op":"insert","k":1,"v":1}`},
+		{"invalid degree", `{"op":"init","degree":1}`},
+		{"unknown op", "{\"op\":\"init\",\"degree\":2}\n{\"op\":\"scan\",\"k\":1}"},
+		{"missing key", "{\"op\":\"init\",\"degree\":2}\n{\"op\":\"get\"}"},
+		{"bad json", "{\"op\":\"init\",\"degree\":2}\n{\"op\":"},
+	}
+
+	for _, test := range tests {
+		if _, err := Replay[int, int](strings.NewReader(test.trace)); err == nil {
+			t.Errorf("Replay(%v) succeeded; expected an error", test.name)
+		}
+	}
+}
+
+func TestRecordReplayNilValues(t *testing.T) {
+	var trace bytes.Buffer
+	recorder := NewRecorder[int, []int](2, &trace)
+	recorder.Insert(1, nil)
+	recorder.Insert(2, []int{2})
+	recorder.Insert(3, nil)
+	recorder.Get(1)
+
+	replayed, err := Replay[int, []int](&trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, expected := range map[int][]int{1: nil, 2: {2}, 3: nil} {
+		if v, found := replayed.Get(k); !found || len(v) != len(expected) {
+			t.Errorf("Get(%v) = (%v, %v) after replay; expected (%v, true)", k, v, found, expected)
+		}
+	}
+}
+
+func TestRecorderWriteError(t *testing.T) {
+	recorder := NewRecorder[int, int](2, failingWriter{})
+	recorder.Insert(1, 1)
+	if recorder.Err() == nil {
+		t.Errorf("Err() = nil; expected the write error")
+	}
+	if _, found := recorder.Get(1); !found {
+		t.Errorf("Recorder stopped applying calls after a write error")
+	}
+}
@@ -0,0 +1,351 @@
+package btree
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+const (
+	opSnapshot = "snapshot"
+	opItem     = "item"
+)
+
+var (
+	// A follower received a change which does not directly follow the last one it applied
+	ErrReplicationGap = errors.New("btree: gap in replication stream")
+	// A follower asked to resume from a sequence number the leader has not reached
+	ErrFollowerAhead = errors.New("btree: follower is ahead of the leader")
+)
+
+/*
+A single line of a replication stream. Changes carry the sequence number assigned
+by the leader. A snapshot record holds the sequence number of the last change it
+includes, and is followed by Len item records. Value is a plain V, since nil is
+a valid value for slices, maps, pointers and interfaces. It is only meaningful for
+insert and item records
+*/
+type replicationRecord[K cmp.Ordered, V any] struct {
+	Seq    uint64 `json:"seq,omitempty"`
+	Op     string `json:"op"`
+	Degree int    `json:"degree,omitempty"`
+	Len    int    `json:"len,omitempty"`
+	Key    *K     `json:"k,omitempty"`
+	Value  V      `json:"v"`
+}
+
+/*
+Replicator wraps a BTree and ships every change made through it to followers, as
+a stream of JSON lines. Each Insert, and each Delete which removes a key, is
+assigned the next sequence number, starting at 1. The most recent changes are
+retained, so followers which fall behind can catch up from the tail of the log,
+and otherwise from a snapshot. Keys and values must be encodable as JSON.
+
+Writes to followers are synchronous, so a slow follower slows the leader down. A
+follower whose writer returns an error is detached. A Replicator is safe for
+concurrent use
+*/
+type Replicator[K cmp.Ordered, V any] struct {
+	mu     sync.RWMutex
+	tree   *BTree[K, V]
+	seq    uint64
+	retain int
+	// Ring buffer of the last retain changes. Change seq is at (seq-1) % retain
+	log       [][]byte
+	followers []io.Writer
+}
+
+/*
+Creates a Replicator for tree, retaining the last retain changes for catching up.
+The tree must not be modified other than through the Replicator afterwards
+*/
+func NewReplicator[K cmp.Ordered, V any](tree *BTree[K, V], retain int) *Replicator[K, V] {
+	if retain < 0 {
+		panic("Invalid retained log size. Must not be negative")
+	}
+	return &Replicator[K, V]{tree: tree, retain: retain}
+}
+
+/*
+Returns the sequence number of the last change
+*/
+func (r *Replicator[K, V]) Seq() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.seq
+}
+
+/*
+Returns the number of attached followers
+*/
+func (r *Replicator[K, V]) Followers() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.followers)
+}
+
+/*
+Returns the value of key k. Success is indicated by returned bool
+*/
+func (r *Replicator[K, V]) Get(k K) (V, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tree.Get(k)
+}
+
+/*
+Insert key,value pair into the tree and ship the change to followers. Returns an
+error if the change cannot be encoded, in which case the tree is unchanged
+*/
+func (r *Replicator[K, V]) Insert(k K, v V) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	line, err := encodeRecord(replicationRecord[K, V]{Seq: r.seq + 1, Op: opInsert, Key: &k, Value: v})
+	if err != nil {
+		return err
+	}
+	r.tree.Insert(k, v)
+	r.publish(line)
+	return nil
+}
+
+/*
+Delete key k from the tree, shipping the change to followers if k was present.
+Returns whether k was present
+*/
+func (r *Replicator[K, V]) Delete(k K) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, found := r.tree.Get(k); !found {
+		return false, nil
+	}
+	line, err := encodeRecord(replicationRecord[K, V]{Seq: r.seq + 1, Op: opDelete, Key: &k})
+	if err != nil {
+		return false, err
+	}
+	r.tree.Delete(k)
+	r.publish(line)
+	return true, nil
+}
+
+/*
+Attach a follower which has applied every change up to and including sequence
+number from, and ship all later changes to w. Changes still retained are written
+to w right away. If some of them have been dropped, or from is 0, a snapshot of
+the tree is written instead. Returns an error if catching up fails, in which case
+w is not attached
+*/
+func (r *Replicator[K, V]) Attach(w io.Writer, from uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if from > r.seq {
+		return fmt.Errorf("%w: follower at %d, leader at %d", ErrFollowerAhead, from, r.seq)
+	}
+
+	oldest := r.seq - uint64(len(r.log)) + 1
+	if from == 0 || from+1 < oldest {
+		if err := r.writeSnapshot(w); err != nil {
+			return err
+		}
+	} else {
+		for seq := from + 1; seq <= r.seq; seq++ {
+			if _, err := w.Write(r.log[(seq-1)%uint64(r.retain)]); err != nil {
+				return fmt.Errorf("btree: writing replication log: %w", err)
+			}
+		}
+	}
+	r.followers = append(r.followers, w)
+	return nil
+}
+
+/*
+Stops shipping changes to w. Returns whether w was attached
+*/
+func (r *Replicator[K, V]) Detach(w io.Writer) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	idx := slices.Index(r.followers, w)
+	if idx < 0 {
+		return false
+	}
+	r.followers = slices.Delete(r.followers, idx, idx+1)
+	return true
+}
+
+// Assigns the next sequence number to the encoded change, retains it and writes it to every follower
+func (r *Replicator[K, V]) publish(line []byte) {
+	r.seq++
+	if r.retain > 0 {
+		if len(r.log) < r.retain {
+			r.log = append(r.log, line)
+		} else {
+			r.log[(r.seq-1)%uint64(r.retain)] = line
+		}
+	}
+	r.followers = slices.DeleteFunc(r.followers, func(w io.Writer) bool {
+		_, err := w.Write(line)
+		return err != nil
+	})
+}
+
+// Writes the tree as a snapshot record followed by one item record per item
+func (r *Replicator[K, V]) writeSnapshot(w io.Writer) error {
+	header := replicationRecord[K, V]{Seq: r.seq, Op: opSnapshot, Degree: r.tree.degree, Len: r.tree.Len()}
+	line, err := encodeRecord(header)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(line); err != nil {
+		return fmt.Errorf("btree: writing replication snapshot: %w", err)
+	}
+	for k, v := range r.tree.All() {
+		line, err := encodeRecord(replicationRecord[K, V]{Op: opItem, Key: &k, Value: v})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(line); err != nil {
+			return fmt.Errorf("btree: writing replication snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+func encodeRecord[K cmp.Ordered, V any](rec replicationRecord[K, V]) ([]byte, error) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("btree: encoding replication record: %w", err)
+	}
+	return append(line, '\n'), nil
+}
+
+/*
+Follower maintains a read replica of a tree from the stream of a Replicator. It
+remembers the sequence number of the last change applied, so it can resume after
+a disconnect by attaching again from Seq. Changes it has already applied are
+skipped, so streams may overlap. A Follower is safe for concurrent use
+*/
+type Follower[K cmp.Ordered, V any] struct {
+	mu   sync.RWMutex
+	tree *BTree[K, V]
+	seq  uint64
+}
+
+/*
+Creates a follower with an empty tree of the given degree, which has applied no changes
+*/
+func NewFollower[K cmp.Ordered, V any](degree int) *Follower[K, V] {
+	return &Follower[K, V]{tree: NewBtree[K, V](degree)}
+}
+
+/*
+Returns the sequence number of the last change applied
+*/
+func (f *Follower[K, V]) Seq() uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.seq
+}
+
+/*
+Returns the value of key k. Success is indicated by returned bool
+*/
+func (f *Follower[K, V]) Get(k K) (V, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.tree.Get(k)
+}
+
+/*
+Calls fn with the replicated tree, during which no changes are applied. fn must
+not modify the tree or retain it
+*/
+func (f *Follower[K, V]) View(fn func(t *BTree[K, V])) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	fn(f.tree)
+}
+
+/*
+Applies the replication stream read from r until it ends. Returns nil at the end
+of the stream. On error, every change before the failing record has been applied,
+and the follower can resume from Seq
+*/
+func (f *Follower[K, V]) Apply(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for {
+		var rec replicationRecord[K, V]
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("btree: reading replication record after %d: %w", f.Seq(), err)
+		}
+
+		if rec.Op == opSnapshot {
+			err = f.applySnapshot(dec, rec)
+		} else {
+			err = f.applyChange(rec)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (f *Follower[K, V]) applyChange(rec replicationRecord[K, V]) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if rec.Seq <= f.seq {
+		return nil
+	}
+	if rec.Seq != f.seq+1 {
+		return fmt.Errorf("%w: expected %d, got %d", ErrReplicationGap, f.seq+1, rec.Seq)
+	}
+	if rec.Key == nil {
+		return fmt.Errorf("btree: replication record %d has no key", rec.Seq)
+	}
+
+	switch rec.Op {
+	case opInsert:
+		f.tree.Insert(*rec.Key, rec.Value)
+	case opDelete:
+		f.tree.Delete(*rec.Key)
+	default:
+		return fmt.Errorf("btree: replication record %d has unknown operation %q", rec.Seq, rec.Op)
+	}
+	f.seq = rec.Seq
+	return nil
+}
+
+/*
+Reads the items of the snapshot described by header from dec. If the snapshot is
+newer than the tree, it replaces the tree once complete
+*/
+func (f *Follower[K, V]) applySnapshot(dec *json.Decoder, header replicationRecord[K, V]) error {
+	if header.Degree < 2 || header.Len < 0 {
+		return fmt.Errorf("btree: invalid replication snapshot at %d", header.Seq)
+	}
+	tree := NewBtree[K, V](header.Degree)
+	for idx := range header.Len {
+		var rec replicationRecord[K, V]
+		if err := dec.Decode(&rec); err != nil {
+			return fmt.Errorf("btree: reading replication snapshot item %d of %d: %w", idx+1, header.Len, err)
+		}
+		if rec.Op != opItem || rec.Key == nil {
+			return fmt.Errorf("btree: invalid replication snapshot item %d of %d", idx+1, header.Len)
+		}
+		tree.Insert(*rec.Key, rec.Value)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if header.Seq > f.seq || (header.Seq == 0 && f.seq == 0) {
+		f.tree, f.seq = tree, header.Seq
+	}
+	return nil
+}
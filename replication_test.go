@@ -0,0 +1,255 @@
+package btree
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// Starts applying everything written to the returned writer to f. Closing the writer ends Apply, whose error is sent on the channel
+func followOverPipe[K cmp.Ordered, V any](f *Follower[K, V]) (*io.PipeWriter, <-chan error) {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := f.Apply(pr)
+		pr.CloseWithError(err)
+		done <- err
+	}()
+	return pw, done
+}
+
+func assertReplicated[K cmp.Ordered, V any](t *testing.T, r *Replicator[K, V], f *Follower[K, V]) {
+	t.Helper()
+	if f.Seq() != r.Seq() {
+		t.Errorf("follower at %d; expected %d", f.Seq(), r.Seq())
+	}
+	f.View(func(tree *BTree[K, V]) {
+		for e := range Diff(r.tree, tree) {
+			t.Errorf("follower differs from leader at key %v: %v", e.Key, e.Kind)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestReplicationLive(t *testing.T) {
+	leader := NewReplicator(NewBtree[int, string](3), 100)
+	followers := []*Follower[int, string]{NewFollower[int, string](3), NewFollower[int, string](8)}
+	var pipes []*io.PipeWriter
+	var done []<-chan error
+	for _, f := range followers {
+		pw, d := followOverPipe(f)
+		if err := leader.Attach(pw, f.Seq()); err != nil {
+			t.Fatal(err)
+		}
+		pipes, done = append(pipes, pw), append(done, d)
+	}
+
+	r := rand.New(rand.NewPCG(424242, 1024))
+	for i := range 2000 {
+		k := r.IntN(500)
+		if r.IntN(3) == 0 {
+			if _, err := leader.Delete(k); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := leader.Insert(k, strings.Repeat("x", i%7)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, f := range followers {
+		leader.Detach(pipes[i])
+		pipes[i].Close()
+		if err := <-done[i]; err != nil {
+			t.Fatal(err)
+		}
+		assertReplicated(t, leader, f)
+	}
+	if leader.Followers() != 0 {
+		t.Errorf("Followers() = %v after detaching all; expected 0", leader.Followers())
+	}
+}
+
+func TestReplicationCatchUp(t *testing.T) {
+	tree := NewBtree[int, int](4)
+	for i := range 100 {
+		tree.Insert(i, i)
+	}
+	leader := NewReplicator(tree, 10)
+	follower := NewFollower[int, int](4)
+
+	// Attaches the follower, makes changes, and detaches it again. Returns the catch-up written on attaching
+	session := func(changes int) string {
+		t.Helper()
+		var catchUp bytes.Buffer
+		pw, done := followOverPipe(follower)
+		w := io.MultiWriter(pw, &catchUp)
+		if err := leader.Attach(w, follower.Seq()); err != nil {
+			t.Fatal(err)
+		}
+		written := catchUp.String()
+		for i := range changes {
+			leader.Insert(1000+i, int(leader.Seq()))
+		}
+		leader.Detach(w)
+		pw.Close()
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+		assertReplicated(t, leader, follower)
+		return written
+	}
+
+	// A new follower starts from a snapshot, even though nothing was dropped from the log
+	if got := session(5); !strings.Contains(got, `"op":"snapshot"`) {
+		t.Errorf("new follower did not receive a snapshot:\n%v", got)
+	}
+
+	// A short absence is covered by the retained log
+	leader.Delete(3)
+	leader.Insert(3, -3)
+	if got := session(0); strings.Contains(got, `"op":"snapshot"`) || strings.Count(got, "\n") != 2 {
+		t.Errorf("follower 2 changes behind received\n%v\nexpected only the 2 changes", got)
+	}
+
+	// A longer absence needs a snapshot
+	for i := range 50 {
+		leader.Insert(i, -i)
+	}
+	if got := session(0); !strings.Contains(got, `"op":"snapshot"`) {
+		t.Errorf("follower 50 changes behind did not receive a snapshot:\n%v", got)
+	}
+
+	// An up to date follower receives nothing
+	if got := session(3); got != "" {
+		t.Errorf("follower which is up to date received\n%v", got)
+	}
+
+	// The log wraps around, but still covers an absence of exactly the retained changes
+	for i := range 10 {
+		leader.Insert(i, i)
+	}
+	if got := session(0); strings.Contains(got, `"op":"snapshot"`) || strings.Count(got, "\n") != 10 {
+		t.Errorf("follower 10 changes behind received\n%v\nexpected only the 10 changes", got)
+	}
+}
+
+func TestReplicationIdempotent(t *testing.T) {
+	var stream bytes.Buffer
+	leader := NewReplicator(NewBtree[string, int](2), 1000)
+	if err := leader.Attach(&stream, 0); err != nil {
+		t.Fatal(err)
+	}
+	for i, k := range []string{"a", "b", "c", "d", "e", "f"} {
+		leader.Insert(k, i)
+	}
+	leader.Delete("c")
+	leader.Insert("a", 10)
+
+	follower := NewFollower[string, int](2)
+	for range 3 {
+		if err := follower.Apply(bytes.NewReader(stream.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+		assertReplicated(t, leader, follower)
+	}
+
+	// Resuming from an earlier point than the follower is at overlaps, and is skipped
+	var overlap bytes.Buffer
+	if err := leader.Attach(&overlap, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := follower.Apply(&overlap); err != nil {
+		t.Fatal(err)
+	}
+	assertReplicated(t, leader, follower)
+}
+
+func TestReplicationNilValues(t *testing.T) {
+	leader := NewReplicator(NewBtree[int, []int](2), 10)
+	for k, v := range [][]int{nil, {1}, {}, nil} {
+		if err := leader.Insert(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Once through the live stream, and once from a snapshot
+	for _, from := range []uint64{1, 0} {
+		var stream bytes.Buffer
+		if err := leader.Attach(&stream, from); err != nil {
+			t.Fatal(err)
+		}
+		leader.Detach(&stream)
+		follower := NewFollower[int, []int](2)
+		if from > 0 {
+			if err := follower.Apply(strings.NewReader(`{"seq":1,"op":"insert","k":0,"v":null}` + "\n")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := follower.Apply(&stream); err != nil {
+			t.Fatalf("Apply() from %d = %v", from, err)
+		}
+		if follower.Seq() != leader.Seq() {
+			t.Errorf("follower at %d; expected %d", follower.Seq(), leader.Seq())
+		}
+		for k := range 4 {
+			v, found := follower.Get(k)
+			expected, _ := leader.Get(k)
+			if !found || len(v) != len(expected) {
+				t.Errorf("follower Get(%v) = (%v, %v); expected (%v, true)", k, v, found, expected)
+			}
+		}
+	}
+}
+
+func TestReplicationErrors(t *testing.T) {
+	leader := NewReplicator(NewBtree[int, int](2), 5)
+	leader.Insert(1, 1)
+	if err := leader.Attach(io.Discard, 2); !errors.Is(err, ErrFollowerAhead) {
+		t.Errorf("Attach() ahead of the leader = %v; expected ErrFollowerAhead", err)
+	}
+
+	// A failing follower is detached without affecting the leader
+	pr, pw := io.Pipe()
+	pr.Close()
+	if err := leader.Attach(pw, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := leader.Insert(2, 2); err != nil {
+		t.Fatal(err)
+	}
+	if leader.Followers() != 0 {
+		t.Errorf("failing follower was not detached")
+	}
+	if v, found := leader.Get(2); !found || v != 2 {
+		t.Errorf("Get(2) = (%v, %v); expected (2, true)", v, found)
+	}
+
+	tests := []struct {
+		name   string
+		stream string
+		err    error
+		seq    uint64
+	}{
+		{"gap", `{"seq":1,"op":"insert","k":1,"v":1}` + "\n" + `{"seq":3,"op":"insert","k":3,"v":3}`, ErrReplicationGap, 1},
+		{"missing key", `{"seq":1,"op":"delete"}`, nil, 0},
+		{"unknown operation", `{"seq":1,"op":"upsert","k":1,"v":1}`, nil, 0},
+		{"truncated snapshot", `{"seq":4,"op":"snapshot","degree":2,"len":2}` + "\n" + `{"op":"item","k":1,"v":1}`, nil, 0},
+		{"malformed", `{"seq":1,"op":"insert","k":"one","v":1}`, nil, 0},
+	}
+	for _, test := range tests {
+		follower := NewFollower[int, int](2)
+		err := follower.Apply(strings.NewReader(test.stream))
+		if err == nil || (test.err != nil && !errors.Is(err, test.err)) {
+			t.Errorf("%v: Apply() = %v; expected an error", test.name, err)
+		}
+		if follower.Seq() != test.seq {
+			t.Errorf("%v: follower at %d after error; expected %d", test.name, follower.Seq(), test.seq)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package respkv
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Number of keys SCAN examines when no COUNT is given
+	defaultScanCount = 10
+	// Number of SCAN cursors remembered. Older cursors become invalid
+	maxCursors = 4096
+)
+
+type command struct {
+	fn func(s *Server, w writer, args [][]byte)
+	// Bounds on the number of arguments, excluding the command name. A negative
+	// maxArgs means no upper limit
+	minArgs, maxArgs int
+}
+
+var commands = map[string]command{
+	"PING":       {ping, 0, 1},
+	"ECHO":       {echo, 1, 1},
+	"GET":        {get, 1, 1},
+	"SET":        {set, 2, 3},
+	"DEL":        {del, 1, -1},
+	"EXISTS":     {exists, 1, -1},
+	"DBSIZE":     {dbsize, 0, 0},
+	"SCAN":       {scan, 1, 5},
+	"RANGEBYLEX": {rangeByLex, 2, 6},
+	"COMMAND":    {commandCmd, 0, -1},
+}
+
+/*
+Executes a command and writes its reply. Returns true if the connection should
+be closed after the reply
+*/
+func (s *Server) execute(w writer, args [][]byte) bool {
+	name := strings.ToUpper(string(args[0]))
+	if name == "QUIT" {
+		w.simple("OK")
+		return true
+	}
+
+	cmd, found := commands[name]
+	if !found {
+		w.errorf("ERR unknown command '%s'", args[0])
+		return false
+	}
+	n := len(args) - 1
+	if n < cmd.minArgs || (cmd.maxArgs >= 0 && n > cmd.maxArgs) {
+		w.errorf("ERR wrong number of arguments for '%s' command", strings.ToLower(name))
+		return false
+	}
+	cmd.fn(s, w, args[1:])
+	return false
+}
+
+func ping(s *Server, w writer, args [][]byte) {
+	if len(args) == 1 {
+		w.bulk(args[0])
+		return
+	}
+	w.simple("PONG")
+}
+
+func echo(s *Server, w writer, args [][]byte) {
+	w.bulk(args[0])
+}
+
+func get(s *Server, w writer, args [][]byte) {
+	s.mu.RLock()
+	value, found := s.tree.Get(string(args[0]))
+	s.mu.RUnlock()
+
+	if !found {
+		w.bulk(nil)
+		return
+	}
+	w.value(value)
+}
+
+/*
+SET key value [NX|XX]. Replies OK, or the null bulk string if the condition was
+not met
+*/
+func set(s *Server, w writer, args [][]byte) {
+	key, value := string(args[0]), args[1]
+	var nx, xx bool
+	if len(args) == 3 {
+		switch strings.ToUpper(string(args[2])) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	stored := true
+	switch {
+	case nx:
+		_, exists := s.tree.GetOrInsert(key, value)
+		stored = !exists
+	case xx:
+		_, stored = s.tree.Get(key)
+		if stored {
+			s.tree.Insert(key, value)
+		}
+	default:
+		s.tree.Insert(key, value)
+	}
+	s.mu.Unlock()
+
+	if !stored {
+		w.bulk(nil)
+		return
+	}
+	w.simple("OK")
+}
+
+func del(s *Server, w writer, args [][]byte) {
+	deleted := 0
+	s.mu.Lock()
+	for _, arg := range args {
+		if s.tree.Delete(string(arg)) {
+			deleted++
+		}
+	}
+	s.mu.Unlock()
+	w.integer(deleted)
+}
+
+// Counts the keys which exist. Keys given several times are counted each time
+func exists(s *Server, w writer, args [][]byte) {
+	count := 0
+	s.mu.RLock()
+	for _, arg := range args {
+		if _, found := s.tree.Get(string(arg)); found {
+			count++
+		}
+	}
+	s.mu.RUnlock()
+	w.integer(count)
+}
+
+func dbsize(s *Server, w writer, args [][]byte) {
+	s.mu.RLock()
+	n := s.tree.Len()
+	s.mu.RUnlock()
+	w.integer(n)
+}
+
+/*
+SCAN cursor [MATCH pattern] [COUNT count]. Keys are returned in ascending order.
+The cursor stands for the next key to examine, so every key present for the whole
+scan is returned exactly once
+*/
+func scan(s *Server, w writer, args [][]byte) {
+	cursor, err := strconv.ParseUint(string(args[0]), 10, 64)
+	if err != nil {
+		w.error("ERR invalid cursor")
+		return
+	}
+	var pattern []byte
+	count := defaultScanCount
+	for i := 1; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			w.error("ERR syntax error")
+			return
+		}
+		switch strings.ToUpper(string(args[i])) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			count, err = strconv.Atoi(string(args[i+1]))
+			if err != nil || count < 1 {
+				w.error("ERR value is not an integer or out of range")
+				return
+			}
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+
+	var start string
+	if cursor != 0 {
+		var found bool
+		if start, found = s.cursors.get(cursor); !found {
+			w.error("ERR invalid cursor")
+			return
+		}
+	}
+
+	var keys []string
+	next := uint64(0)
+	examined := 0
+	s.mu.RLock()
+	for k := range s.tree.AscendFrom(start) {
+		if examined == count {
+			next = s.cursors.put(k)
+			break
+		}
+		examined++
+		if pattern == nil || matchGlob(pattern, []byte(k)) {
+			keys = append(keys, k)
+		}
+	}
+	s.mu.RUnlock()
+
+	w.array(2)
+	w.bulkString(strconv.FormatUint(next, 10))
+	w.array(len(keys))
+	for _, k := range keys {
+		w.bulkString(k)
+	}
+}
+
+/*
+RANGEBYLEX min max [LIMIT offset count] [WITHVALUES]. Bounds are "-" and "+" for
+negative and positive infinity, or a key prefixed with "[" for inclusive and "("
+for exclusive bounds. A negative count means no limit. WITHVALUES replies key,
+value pairs
+*/
+func rangeByLex(s *Server, w writer, args [][]byte) {
+	from, fromOK := parseLexBound(args[0])
+	to, toOK := parseLexBound(args[1])
+	if !fromOK || !toOK {
+		w.error("ERR min or max not valid string range item")
+		return
+	}
+
+	offset, limit := 0, -1
+	withValues := false
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(string(args[i])) {
+		case "LIMIT":
+			if i+2 >= len(args) {
+				w.error("ERR syntax error")
+				return
+			}
+			var errOffset, errLimit error
+			offset, errOffset = strconv.Atoi(string(args[i+1]))
+			limit, errLimit = strconv.Atoi(string(args[i+2]))
+			if errOffset != nil || errLimit != nil {
+				w.error("ERR value is not an integer or out of range")
+				return
+			}
+			i += 2
+		case "WITHVALUES":
+			withValues = true
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+
+	var keys []string
+	var values [][]byte
+	s.mu.RLock()
+	if from.kind != lexPosInf && offset >= 0 && limit != 0 {
+		skipped := 0
+		for k, v := range s.tree.AscendFrom(from.key) {
+			if from.kind == lexExclusive && k == from.key {
+				continue
+			}
+			if to.below(k) {
+				break
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			keys = append(keys, k)
+			values = append(values, v)
+			if len(keys) == limit {
+				break
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	if withValues {
+		w.array(2 * len(keys))
+	} else {
+		w.array(len(keys))
+	}
+	for i, k := range keys {
+		w.bulkString(k)
+		if withValues {
+			w.value(values[i])
+		}
+	}
+}
+
+type lexBoundKind int
+
+const (
+	lexNegInf lexBoundKind = iota
+	lexPosInf
+	lexInclusive
+	lexExclusive
+)
+
+type lexBound struct {
+	kind lexBoundKind
+	key  string
+}
+
+// Parses a ZRANGEBYLEX style bound. Returns false if b is not a valid bound
+func parseLexBound(b []byte) (lexBound, bool) {
+	switch {
+	case bytes.Equal(b, []byte("-")):
+		return lexBound{kind: lexNegInf}, true
+	case bytes.Equal(b, []byte("+")):
+		return lexBound{kind: lexPosInf}, true
+	case len(b) > 0 && b[0] == '[':
+		return lexBound{kind: lexInclusive, key: string(b[1:])}, true
+	case len(b) > 0 && b[0] == '(':
+		return lexBound{kind: lexExclusive, key: string(b[1:])}, true
+	default:
+		return lexBound{}, false
+	}
+}
+
+// Reports whether k is past the bound b, when b is used as an upper bound
+func (b lexBound) below(k string) bool {
+	switch b.kind {
+	case lexNegInf:
+		return true
+	case lexInclusive:
+		return k > b.key
+	case lexExclusive:
+		return k >= b.key
+	default:
+		return false
+	}
+}
+
+// redis-cli asks for command documentation on connect. An empty reply is enough
+func commandCmd(s *Server, w writer, args [][]byte) {
+	w.array(0)
+}
@@ -0,0 +1,47 @@
+package respkv
+
+import (
+	"sync"
+)
+
+/*
+cursorTable maps the numeric cursors Redis clients expect to the key a SCAN
+continues from. Only the most recent cursors are kept, so abandoned scans do not
+leak memory. Cursors are never reused, and 0 is reserved for the start and end
+of a scan
+*/
+type cursorTable struct {
+	mu   sync.Mutex
+	next uint64
+	// Ring buffer of the keys of the last len(keys) cursors. Cursor c is at (c-1) % len(keys)
+	keys []string
+}
+
+func newCursorTable(size int) *cursorTable {
+	return &cursorTable{next: 1, keys: make([]string, size)}
+}
+
+// Registers a cursor continuing from key, evicting the oldest cursor if the table is full
+func (c *cursorTable) put(key string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cursor := c.next
+	c.next++
+	c.keys[c.slot(cursor)] = key
+	return cursor
+}
+
+func (c *cursorTable) get(cursor uint64) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Cursors older than the last len(keys) have been overwritten
+	if cursor == 0 || cursor >= c.next || c.next-cursor > uint64(len(c.keys)) {
+		return "", false
+	}
+	return c.keys[c.slot(cursor)], true
+}
+
+func (c *cursorTable) slot(cursor uint64) uint64 {
+	return (cursor - 1) % uint64(len(c.keys))
+}
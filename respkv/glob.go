@@ -0,0 +1,96 @@
+package respkv
+
+/*
+Reports whether s matches the Redis style glob pattern. "*" matches any sequence,
+"?" any single byte, "[abc]", "[a-z]" and "[^a]" sets of bytes, and "\" escapes
+the following byte
+*/
+func matchGlob(pattern, s []byte) bool {
+	// Position to retry from after the last "*", letting it absorb one more byte
+	starPattern, starS := -1, 0
+	p, i := 0, 0
+	for i < len(s) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				starPattern, starS = p, i
+				p++
+				continue
+			case '?':
+				p++
+				i++
+				continue
+			case '[':
+				matched, end, ok := matchClass(pattern, p, s[i])
+				if !ok {
+					// An unclosed "[" is a literal
+					matched, end = s[i] == '[', p+1
+				}
+				if matched {
+					p = end
+					i++
+					continue
+				}
+			case '\\':
+				if p+1 < len(pattern) && pattern[p+1] == s[i] {
+					p += 2
+					i++
+					continue
+				}
+			default:
+				if pattern[p] == s[i] {
+					p++
+					i++
+					continue
+				}
+			}
+		}
+		if starPattern < 0 {
+			return false
+		}
+		starS++
+		p, i = starPattern+1, starS
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+/*
+Matches b against the class starting at pattern[start] == '['. Returns whether it
+matched, the index after the closing "]", and false if the class is not closed
+*/
+func matchClass(pattern []byte, start int, b byte) (bool, int, bool) {
+	p := start + 1
+	negate := p < len(pattern) && pattern[p] == '^'
+	if negate {
+		p++
+	}
+
+	matched := false
+	for first := true; p < len(pattern) && (first || pattern[p] != ']'); first = false {
+		lo := pattern[p]
+		if lo == '\\' && p+1 < len(pattern) {
+			p++
+			lo = pattern[p]
+		}
+		hi := lo
+		if p+2 < len(pattern) && pattern[p+1] == '-' && pattern[p+2] != ']' {
+			hi = pattern[p+2]
+			p += 2
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+		}
+		if lo <= b && b <= hi {
+			matched = true
+		}
+		p++
+	}
+	if p >= len(pattern) {
+		return false, 0, false
+	}
+	return matched != negate, p + 1, true
+}
@@ -0,0 +1,46 @@
+package respkv
+
+import (
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"", "", true},
+		{"", "a", false},
+		{"user:*", "user:1", true},
+		{"user:*", "users:1", false},
+		{"*:name", "user:1:name", true},
+		{"*:name", "user:1:names", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[c-a]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{`[\]]`, "]", true},
+		{"a[b", "a[b", true},
+		{"a[b", "ab", false},
+		{"**a", "ba", true},
+		{"a/*", "a/b/c", true},
+	}
+
+	for _, test := range tests {
+		if got := matchGlob([]byte(test.pattern), []byte(test.s)); got != test.match {
+			t.Errorf("matchGlob(%q, %q) = %v; expected %v", test.pattern, test.s, got, test.match)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package respkv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	// Largest number of arguments accepted in one command
+	maxArgs = 1 << 20
+	// Largest bulk string accepted in a command
+	maxBulkLen = 64 << 20
+)
+
+// ProtocolError is a malformed request. The connection is closed after replying
+type ProtocolError struct {
+	msg string
+}
+
+func (e *ProtocolError) Error() string {
+	return "Protocol error: " + e.msg
+}
+
+func protocolErrorf(format string, args ...any) error {
+	return &ProtocolError{msg: fmt.Sprintf(format, args...)}
+}
+
+/*
+Reads one command, either as a RESP array of bulk strings or as an inline command
+of space separated words. Returns an empty command for blank inline lines
+*/
+func readCommand(r *bufio.Reader) ([][]byte, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		fields := bytes.Fields(line)
+		args := make([][]byte, len(fields))
+		for i, field := range fields {
+			args[i] = bytes.Clone(field)
+		}
+		return args, nil
+	}
+
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n > maxArgs {
+		return nil, protocolErrorf("invalid multibulk length")
+	}
+	args := make([][]byte, 0, max(n, 0))
+	for range n {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, protocolErrorf("expected '$', got '%s'", line)
+		}
+		size, err := strconv.Atoi(string(line[1:]))
+		if err != nil || size < 0 || size > maxBulkLen {
+			return nil, protocolErrorf("invalid bulk length")
+		}
+
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(r, arg); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if arg[size] != '\r' || arg[size+1] != '\n' {
+			return nil, protocolErrorf("bulk string not terminated by CRLF")
+		}
+		args = append(args, arg[:size:size])
+	}
+	return args, nil
+}
+
+/*
+Reads a line terminated by LF or CRLF, without the terminator. The returned slice
+is only valid until the next read
+*/
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return nil, protocolErrorf("too big request line")
+	}
+	if err != nil {
+		if len(line) > 0 {
+			return nil, unexpectedEOF(err)
+		}
+		return nil, err
+	}
+	line = line[:len(line)-1]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line, nil
+}
+
+// A connection closing in the middle of a command is an error, not a clean close
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+/*
+writer encodes RESP2 replies. Write errors are sticky in the underlying bufio.Writer
+and surface on Flush
+*/
+type writer struct {
+	*bufio.Writer
+}
+
+func (w writer) simple(s string) {
+	w.WriteByte('+')
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func (w writer) error(msg string) {
+	w.WriteByte('-')
+	w.WriteString(msg)
+	w.WriteString("\r\n")
+}
+
+func (w writer) errorf(format string, args ...any) {
+	w.error(fmt.Sprintf(format, args...))
+}
+
+func (w writer) integer(n int) {
+	w.WriteByte(':')
+	w.WriteString(strconv.Itoa(n))
+	w.WriteString("\r\n")
+}
+
+// Writes b as a bulk string, or the null bulk string if b is nil
+func (w writer) bulk(b []byte) {
+	if b == nil {
+		w.WriteString("$-1\r\n")
+		return
+	}
+	w.WriteByte('$')
+	w.WriteString(strconv.Itoa(len(b)))
+	w.WriteString("\r\n")
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+/*
+Writes a stored value as a bulk string. Stored values are never null, but empty
+values may be stored as nil, as snapshots decode them that way
+*/
+func (w writer) value(v []byte) {
+	if v == nil {
+		v = []byte{}
+	}
+	w.bulk(v)
+}
+
+func (w writer) bulkString(s string) {
+	w.bulk([]byte(s))
+}
+
+// Writes the header of an array of n elements, which must follow
+func (w writer) array(n int) {
+	w.WriteByte('*')
+	w.WriteString(strconv.Itoa(n))
+	w.WriteString("\r\n")
+}
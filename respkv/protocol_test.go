@@ -0,0 +1,80 @@
+package respkv
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		err      bool
+	}{
+		{"array", "*2\r\n$3\r\nGET\r\n$1\r\na\r\n", []string{"GET", "a"}, false},
+		{"binary safe", "*2\r\n$3\r\nSET\r\n$4\r\na\r\nb\r\n", []string{"SET", "a\r\nb"}, false},
+		{"empty bulk", "*1\r\n$0\r\n\r\n", []string{""}, false},
+		{"empty array", "*0\r\n", []string{}, false},
+		{"inline", "SET a  b\r\n", []string{"SET", "a", "b"}, false},
+		{"inline LF", "PING\n", []string{"PING"}, false},
+		{"blank inline", "\r\n", []string{}, false},
+		{"bad array length", "*x\r\n", nil, true},
+		{"missing dollar", "*1\r\n:1\r\n", nil, true},
+		{"bad bulk length", "*1\r\n$-1\r\n", nil, true},
+		{"unterminated bulk", "*1\r\n$1\r\nab\r\n", nil, true},
+		{"truncated bulk", "*1\r\n$5\r\nab", nil, true},
+		{"truncated array", "*2\r\n$1\r\na\r\n", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, err := readCommand(bufio.NewReader(strings.NewReader(test.input)))
+			if (err != nil) != test.err {
+				t.Fatalf("readCommand() error = %v; expected error %v", err, test.err)
+			}
+			if test.err {
+				if errors.Is(err, io.EOF) {
+					t.Errorf("readCommand() = io.EOF; expected a protocol error or io.ErrUnexpectedEOF")
+				}
+				return
+			}
+			if len(args) != len(test.expected) {
+				t.Fatalf("readCommand() = %q; expected %q", args, test.expected)
+			}
+			for i := range args {
+				if string(args[i]) != test.expected[i] {
+					t.Errorf("readCommand() = %q; expected %q", args, test.expected)
+				}
+			}
+		})
+	}
+
+	if _, err := readCommand(bufio.NewReader(strings.NewReader(""))); !errors.Is(err, io.EOF) {
+		t.Errorf("readCommand() at end of input = %v; expected io.EOF", err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var sb strings.Builder
+	w := writer{bufio.NewWriter(&sb)}
+	w.simple("OK")
+	w.error("ERR bad")
+	w.integer(-3)
+	w.bulk([]byte("a\r\nb"))
+	w.bulk([]byte{})
+	w.bulk(nil)
+	w.array(1)
+	w.bulkString("x")
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "+OK\r\n-ERR bad\r\n:-3\r\n$4\r\na\r\nb\r\n$0\r\n\r\n$-1\r\n*1\r\n$1\r\nx\r\n"
+	if sb.String() != expected {
+		t.Errorf("writer wrote %q; expected %q", sb.String(), expected)
+	}
+}
@@ -0,0 +1,185 @@
+// Package respkv serves a btree over the Redis RESP2 protocol, so that Redis
+// clients and redis-cli can use it as a local ordered key-value store.
+//
+// Supported commands are GET, SET, DEL, EXISTS, SCAN, RANGEBYLEX, DBSIZE, PING,
+// ECHO, COMMAND and QUIT. RANGEBYLEX takes the same bounds as ZRANGEBYLEX but
+// ranges over the whole keyspace instead of a sorted set:
+//
+//	RANGEBYLEX min max [LIMIT offset count] [WITHVALUES]
+package respkv
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/push-and-pray/btree"
+)
+
+// ErrServerClosed is returned by Serve after Close
+var ErrServerClosed = errors.New("respkv: server closed")
+
+/*
+Server serves a btree to RESP2 clients. It is safe for concurrent use
+*/
+type Server struct {
+	mu   sync.RWMutex
+	tree *btree.BTree[string, []byte]
+
+	cursors *cursorTable
+
+	connsMu   sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+/*
+Creates a server for tree. The tree must not be used directly while the server is
+in use
+*/
+func NewServer(tree *btree.BTree[string, []byte]) *Server {
+	return &Server{
+		tree:      tree,
+		cursors:   newCursorTable(maxCursors),
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+/*
+Listens on the TCP address addr and serves connections. Always returns a non-nil error
+*/
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+/*
+Accepts connections on l and serves each in its own goroutine. Returns
+ErrServerClosed after Close, and otherwise the error which stopped Accept
+*/
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l, nil) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(l, nil)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+		if !s.track(nil, conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		go func() {
+			defer s.untrack(nil, conn)
+			s.serveConn(conn)
+		}()
+	}
+}
+
+/*
+Closes all listeners and connections. Commands being executed run to completion
+*/
+func (s *Server) Close() error {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return nil
+}
+
+/*
+Writes a snapshot of the tree to w, as btree.WriteSnapshot. Writes are blocked
+while the snapshot is taken
+*/
+func (s *Server) WriteSnapshot(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tree.WriteSnapshot(w)
+}
+
+/*
+Registers a listener or connection to be closed by Close. Returns false if the
+server is already closed
+*/
+func (s *Server) track(l net.Listener, conn net.Conn) bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	if s.closed {
+		return false
+	}
+	if l != nil {
+		s.listeners[l] = struct{}{}
+	}
+	if conn != nil {
+		s.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (s *Server) untrack(l net.Listener, conn net.Conn) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	if l != nil {
+		delete(s.listeners, l)
+	}
+	if conn != nil {
+		conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+func (s *Server) isClosed() bool {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	return s.closed
+}
+
+/*
+Executes commands from conn until it closes, QUIT is received, or a protocol
+error occurs. Replies are flushed once no pipelined commands are left
+*/
+func (s *Server) serveConn(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	w := writer{bufio.NewWriter(conn)}
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			var protoErr *ProtocolError
+			if errors.As(err, &protoErr) {
+				w.error("ERR " + protoErr.Error())
+				w.Flush()
+			}
+			return
+		}
+
+		quit := false
+		if len(args) > 0 {
+			quit = s.execute(w, args)
+		}
+		if quit || r.Buffered() == 0 {
+			if err := w.Flush(); err != nil || quit {
+				return
+			}
+		}
+	}
+}
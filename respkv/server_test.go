@@ -0,0 +1,429 @@
+package respkv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/push-and-pray/btree"
+)
+
+/*
+testClient is a minimal RESP2 client. Replies are decoded to string for simple
+strings, respError for errors, int for integers, []byte or nil for bulk strings and
+[]any for arrays
+*/
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+type respError string
+
+func startServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	return serveTree(t, btree.NewBtree[string, []byte](2))
+}
+
+func serveTree(t *testing.T, tree *btree.BTree[string, []byte]) (*Server, string) {
+	t.Helper()
+	s := NewServer(tree)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(l) }()
+	t.Cleanup(func() {
+		s.Close()
+		if err := <-done; !errors.Is(err, ErrServerClosed) {
+			t.Errorf("Serve() = %v; expected ErrServerClosed", err)
+		}
+	})
+	return s, l.Addr().String()
+}
+
+func dial(t *testing.T, addr string) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *testClient) send(args ...string) {
+	c.t.Helper()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, sb.String()); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *testClient) do(args ...string) any {
+	c.t.Helper()
+	c.send(args...)
+	return c.read()
+}
+
+func (c *testClient) read() any {
+	c.t.Helper()
+	reply, err := c.readReply()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return reply
+}
+
+func (c *testClient) readReply() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(line, "\r\n") || len(line) < 3 {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return respError(body), nil
+	case ':':
+		return strconv.Atoi(body)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		elems := make([]any, n)
+		for i := range elems {
+			if elems[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return elems, nil
+	}
+	return nil, fmt.Errorf("unknown reply type %q", kind)
+}
+
+// Builds the expected decoding of an array of bulk strings
+func bulks(values ...string) []any {
+	elems := make([]any, len(values))
+	for i, v := range values {
+		elems[i] = []byte(v)
+	}
+	return elems
+}
+
+func TestCommands(t *testing.T) {
+	_, addr := startServer(t)
+	c := dial(t, addr)
+
+	tests := []struct {
+		args     []string
+		expected any
+	}{
+		{[]string{"PING"}, "PONG"},
+		{[]string{"ping", "hi"}, []byte("hi")},
+		{[]string{"ECHO", "a b"}, []byte("a b")},
+		{[]string{"GET", "a"}, nil},
+		{[]string{"SET", "a", "1"}, "OK"},
+		{[]string{"GET", "a"}, []byte("1")},
+		{[]string{"SET", "a", "2", "NX"}, nil},
+		{[]string{"GET", "a"}, []byte("1")},
+		{[]string{"SET", "b", "2", "XX"}, nil},
+		{[]string{"SET", "a", "2", "XX"}, "OK"},
+		{[]string{"SET", "b", "", "nx"}, "OK"},
+		{[]string{"GET", "b"}, []byte("")},
+		{[]string{"SET", "bin", "\x00\r\n\xff"}, "OK"},
+		{[]string{"GET", "bin"}, []byte("\x00\r\n\xff")},
+		{[]string{"EXISTS", "a", "b", "z", "a"}, 3},
+		{[]string{"DBSIZE"}, 3},
+		{[]string{"DEL", "a", "z", "bin"}, 2},
+		{[]string{"EXISTS", "a"}, 0},
+		{[]string{"DBSIZE"}, 1},
+		{[]string{"COMMAND", "DOCS"}, []any{}},
+		{[]string{"SET", "a", "1", "EX"}, respError("ERR syntax error")},
+		{[]string{"GET"}, respError("ERR wrong number of arguments for 'get' command")},
+		{[]string{"FLUSHALL"}, respError("ERR unknown command 'FLUSHALL'")},
+	}
+
+	for _, test := range tests {
+		if got := c.do(test.args...); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q = %#v; expected %#v", test.args, got, test.expected)
+		}
+	}
+}
+
+func TestEmptyValueAfterRestart(t *testing.T) {
+	s, addr := startServer(t)
+	c := dial(t, addr)
+	c.do("SET", "empty", "")
+	c.do("SET", "full", "x")
+
+	var snapshot bytes.Buffer
+	if err := s.WriteSnapshot(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := btree.ReadSnapshot[string, []byte](&snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, addr = serveTree(t, tree)
+	c = dial(t, addr)
+
+	tests := []struct {
+		args     []string
+		expected any
+	}{
+		{[]string{"GET", "empty"}, []byte{}},
+		{[]string{"EXISTS", "empty"}, 1},
+		{[]string{"RANGEBYLEX", "-", "+", "WITHVALUES"}, bulks("empty", "", "full", "x")},
+	}
+	for _, test := range tests {
+		if got := c.do(test.args...); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q after restart = %#v; expected %#v", test.args, got, test.expected)
+		}
+	}
+}
+
+func TestRangeByLex(t *testing.T) {
+	_, addr := startServer(t)
+	c := dial(t, addr)
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		c.do("SET", k, strings.ToUpper(k))
+	}
+
+	tests := []struct {
+		args     []string
+		expected any
+	}{
+		{[]string{"-", "+"}, bulks("a", "b", "c", "d", "e")},
+		{[]string{"[b", "[d"}, bulks("b", "c", "d")},
+		{[]string{"(b", "(d"}, bulks("c")},
+		{[]string{"[bb", "+"}, bulks("c", "d", "e")},
+		{[]string{"-", "(c"}, bulks("a", "b")},
+		{[]string{"+", "-"}, bulks()},
+		{[]string{"-", "-"}, bulks()},
+		{[]string{"+", "+"}, bulks()},
+		{[]string{"[d", "[b"}, bulks()},
+		{[]string{"-", "+", "LIMIT", "1", "2"}, bulks("b", "c")},
+		{[]string{"-", "+", "LIMIT", "3", "-1"}, bulks("d", "e")},
+		{[]string{"-", "+", "LIMIT", "0", "0"}, bulks()},
+		{[]string{"[d", "+", "WITHVALUES"}, bulks("d", "D", "e", "E")},
+		{[]string{"-", "+", "WITHVALUES", "LIMIT", "0", "1"}, bulks("a", "A")},
+		{[]string{"a", "+"}, respError("ERR min or max not valid string range item")},
+		{[]string{"-", "+", "LIMIT", "0"}, respError("ERR syntax error")},
+		{[]string{"-", "+", "LIMIT", "x", "1"}, respError("ERR value is not an integer or out of range")},
+	}
+
+	for _, test := range tests {
+		args := append([]string{"RANGEBYLEX"}, test.args...)
+		if got := c.do(args...); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q = %#v; expected %#v", args, got, test.expected)
+		}
+	}
+}
+
+// Runs a full SCAN with the given options and returns the keys and the number of calls
+func scanAll(c *testClient, opts ...string) ([]string, int) {
+	c.t.Helper()
+	var keys []string
+	cursor := "0"
+	for calls := 1; ; calls++ {
+		reply, ok := c.do(append([]string{"SCAN", cursor}, opts...)...).([]any)
+		if !ok || len(reply) != 2 {
+			c.t.Fatalf("SCAN replied %#v", reply)
+		}
+		for _, k := range reply[1].([]any) {
+			keys = append(keys, string(k.([]byte)))
+		}
+		cursor = string(reply[0].([]byte))
+		if cursor == "0" {
+			return keys, calls
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	_, addr := startServer(t)
+	c := dial(t, addr)
+	var expected []string
+	for i := range 35 {
+		k := fmt.Sprintf("key:%02d", i)
+		c.do("SET", k, "v")
+		expected = append(expected, k)
+	}
+	c.do("SET", "other", "v")
+
+	keys, calls := scanAll(c, "MATCH", "key:*")
+	if !reflect.DeepEqual(keys, expected) || calls != 4 {
+		t.Errorf("SCAN MATCH key:* = %v in %v calls; expected %v in 4 calls", keys, calls, expected)
+	}
+
+	keys, calls = scanAll(c, "COUNT", "100")
+	if len(keys) != 36 || calls != 1 {
+		t.Errorf("SCAN COUNT 100 returned %v keys in %v calls; expected 36 in 1 call", len(keys), calls)
+	}
+
+	keys, _ = scanAll(c, "MATCH", "key:1?")
+	if len(keys) != 10 {
+		t.Errorf("SCAN MATCH key:1? = %v; expected 10 keys", keys)
+	}
+
+	for _, args := range [][]string{
+		{"SCAN", "x"},
+		{"SCAN", "12345"},
+		{"SCAN", "0", "COUNT", "0"},
+		{"SCAN", "0", "MATCH"},
+		{"SCAN", "0", "TYPE", "string"},
+	} {
+		if _, ok := c.do(args...).(respError); !ok {
+			t.Errorf("%q did not reply with an error", args)
+		}
+	}
+}
+
+func TestScanConcurrentWrites(t *testing.T) {
+	_, addr := startServer(t)
+	c := dial(t, addr)
+	for i := range 20 {
+		c.do("SET", fmt.Sprintf("%02d", i), "v")
+	}
+
+	// Keys present for the whole scan are returned exactly once, even when keys
+	// before the cursor are deleted
+	reply := c.do("SCAN", "0", "COUNT", "5").([]any)
+	cursor := string(reply[0].([]byte))
+	c.do("DEL", "00", "01", "02")
+	c.do("SET", "00a", "v")
+
+	var keys []string
+	for cursor != "0" {
+		reply := c.do("SCAN", cursor, "COUNT", "5").([]any)
+		for _, k := range reply[1].([]any) {
+			keys = append(keys, string(k.([]byte)))
+		}
+		cursor = string(reply[0].([]byte))
+	}
+
+	var expected []string
+	for i := 5; i < 20; i++ {
+		expected = append(expected, fmt.Sprintf("%02d", i))
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("SCAN continued with %v; expected %v", keys, expected)
+	}
+}
+
+func TestPipelining(t *testing.T) {
+	_, addr := startServer(t)
+	c := dial(t, addr)
+
+	var sb strings.Builder
+	for i := range 100 {
+		fmt.Fprintf(&sb, "*3\r\n$3\r\nSET\r\n$%d\r\n%d\r\n$1\r\nv\r\n", len(strconv.Itoa(i)), i)
+	}
+	sb.WriteString("PING\r\n")
+	if _, err := io.WriteString(c.conn, sb.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 100 {
+		if got := c.read(); got != "OK" {
+			t.Fatalf("reply %v = %#v; expected OK", i, got)
+		}
+	}
+	if got := c.read(); got != "PONG" {
+		t.Errorf("inline PING = %#v; expected PONG", got)
+	}
+	if got := c.do("DBSIZE"); got != 100 {
+		t.Errorf("DBSIZE = %#v; expected 100", got)
+	}
+}
+
+func TestQuitAndProtocolError(t *testing.T) {
+	_, addr := startServer(t)
+
+	c := dial(t, addr)
+	if got := c.do("QUIT"); got != "OK" {
+		t.Errorf("QUIT = %#v; expected OK", got)
+	}
+	if _, err := c.readReply(); !errors.Is(err, io.EOF) {
+		t.Errorf("connection after QUIT = %v; expected it to be closed", err)
+	}
+
+	c = dial(t, addr)
+	io.WriteString(c.conn, "*1\r\n:1\r\n")
+	if got, ok := c.read().(respError); !ok || !strings.HasPrefix(string(got), "ERR Protocol error") {
+		t.Errorf("malformed command = %#v; expected a protocol error", got)
+	}
+	if _, err := c.readReply(); !errors.Is(err, io.EOF) {
+		t.Errorf("connection after protocol error = %v; expected it to be closed", err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	s, addr := startServer(t)
+	c := dial(t, addr)
+	if got := c.do("PING"); got != "PONG" {
+		t.Fatalf("PING = %#v; expected PONG", got)
+	}
+
+	s.Close()
+	if _, err := c.readReply(); err == nil {
+		t.Errorf("connection after Close() is still open")
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Errorf("listener accepted a connection after Close()")
+	}
+}
+
+func TestCursorTableEviction(t *testing.T) {
+	table := newCursorTable(2)
+	first := table.put("a")
+	second := table.put("b")
+	third := table.put("c")
+
+	if _, found := table.get(first); found {
+		t.Errorf("oldest cursor was not evicted")
+	}
+	for cursor, key := range map[uint64]string{second: "b", third: "c"} {
+		if got, found := table.get(cursor); !found || got != key {
+			t.Errorf("get(%v) = (%v, %v); expected (%v, true)", cursor, got, found, key)
+		}
+	}
+	if first == 0 || second == first || third == second {
+		t.Errorf("cursors %v, %v, %v are not unique and non-zero", first, second, third)
+	}
+	for _, cursor := range []uint64{0, third + 1} {
+		if _, found := table.get(cursor); found {
+			t.Errorf("get(%v) found a cursor which was never issued", cursor)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package btree
+
+import (
+	"cmp"
+	"iter"
+)
+
+// BTreeSet is an ordered set of keys backed by a BTree
+type BTreeSet[K cmp.Ordered] struct {
+	tree *BTree[K, struct{}]
+}
+
+func NewBtreeSet[K cmp.Ordered](degree int) *BTreeSet[K] {
+	return &BTreeSet[K]{tree: NewBtree[K, struct{}](degree)}
+}
+
+/*
+Add key k to the set. Returns whether k was not already present
+*/
+func (s *BTreeSet[K]) Add(k K) bool {
+	_, found := s.tree.GetOrInsert(k, struct{}{})
+	return !found
+}
+
+/*
+Remove key k from the set. Returns whether k was present
+*/
+func (s *BTreeSet[K]) Remove(k K) bool {
+	return s.tree.Delete(k)
+}
+
+/*
+Returns whether key k is in the set
+*/
+func (s *BTreeSet[K]) Contains(k K) bool {
+	_, found := s.tree.Get(k)
+	return found
+}
+
+/*
+Returns the number of keys in the set
+*/
+func (s *BTreeSet[K]) Len() int {
+	return s.tree.Len()
+}
+
+/*
+Returns an iterator over all keys in ascending order. The set must not be
+modified while iterating
+*/
+func (s *BTreeSet[K]) All() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range s.tree.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+/*
+Returns the smallest key. Success is indicated by returned bool
+*/
+func (s *BTreeSet[K]) Min() (K, bool) {
+	k, _, found := s.tree.Min()
+	return k, found
+}
+
+/*
+Returns the largest key. Success is indicated by returned bool
+*/
+func (s *BTreeSet[K]) Max() (K, bool) {
+	k, _, found := s.tree.Max()
+	return k, found
+}
+
+/*
+Returns the largest key less than or equal to k. Success is indicated by returned bool
+*/
+func (s *BTreeSet[K]) Floor(k K) (K, bool) {
+	k, _, found := s.tree.Floor(k)
+	return k, found
+}
+
+/*
+Returns the smallest key greater than or equal to k. Success is indicated by returned bool
+*/
+func (s *BTreeSet[K]) Ceiling(k K) (K, bool) {
+	k, _, found := s.tree.Ceiling(k)
+	return k, found
+}
+
+/*
+Returns a new set with the keys in either s or other. The new set has the degree of s
+*/
+func (s *BTreeSet[K]) Union(other *BTreeSet[K]) *BTreeSet[K] {
+	result := NewBtreeSet[K](s.tree.degree)
+	walkMerged(s.All(), other.All(), func(k K, inS, inOther bool) {
+		result.Add(k)
+	})
+	return result
+}
+
+/*
+Returns a new set with the keys in both s and other. The new set has the degree of s
+*/
+func (s *BTreeSet[K]) Intersection(other *BTreeSet[K]) *BTreeSet[K] {
+	result := NewBtreeSet[K](s.tree.degree)
+	walkMerged(s.All(), other.All(), func(k K, inS, inOther bool) {
+		if inS && inOther {
+			result.Add(k)
+		}
+	})
+	return result
+}
+
+/*
+Returns a new set with the keys in s but not in other. The new set has the degree of s
+*/
+func (s *BTreeSet[K]) Difference(other *BTreeSet[K]) *BTreeSet[K] {
+	result := NewBtreeSet[K](s.tree.degree)
+	walkMerged(s.All(), other.All(), func(k K, inS, inOther bool) {
+		if inS && !inOther {
+			result.Add(k)
+		}
+	})
+	return result
+}
+
+/*
+Returns a new set with the keys in exactly one of s and other. The new set has the degree of s
+*/
+func (s *BTreeSet[K]) SymmetricDifference(other *BTreeSet[K]) *BTreeSet[K] {
+	result := NewBtreeSet[K](s.tree.degree)
+	walkMerged(s.All(), other.All(), func(k K, inS, inOther bool) {
+		if inS != inOther {
+			result.Add(k)
+		}
+	})
+	return result
+}
+
+/*
+Walks two ascending key sequences in lockstep, calling visit once per distinct
+key with whether it occurred in a, b or both. Keys are compared by their natural
+ordering, which both sequences must be sorted by
+*/
+func walkMerged[K cmp.Ordered](a, b iter.Seq[K], visit func(k K, inA, inB bool)) {
+	nextA, stopA := iter.Pull(a)
+	defer stopA()
+	nextB, stopB := iter.Pull(b)
+	defer stopB()
+
+	ka, okA := nextA()
+	kb, okB := nextB()
+	for okA || okB {
+		switch {
+		case !okB || (okA && ka < kb):
+			visit(ka, true, false)
+			ka, okA = nextA()
+		case !okA || kb < ka:
+			visit(kb, false, true)
+			kb, okB = nextB()
+		default:
+			visit(ka, true, true)
+			ka, okA = nextA()
+			kb, okB = nextB()
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package btree
+
+import (
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+func setOf(degree int, keys ...int) *BTreeSet[int] {
+	s := NewBtreeSet[int](degree)
+	for _, k := range keys {
+		s.Add(k)
+	}
+	return s
+}
+
+func TestSetItemHasNoPadding(t *testing.T) {
+	if size := unsafe.Sizeof(Item[int64, struct{}]{}); size != unsafe.Sizeof(int64(0)) {
+		t.Errorf("Item[int64, struct{}] is %v bytes; expected %v", size, unsafe.Sizeof(int64(0)))
+	}
+}
+
+func TestSetAddRemoveContains(t *testing.T) {
+	s := NewBtreeSet[string](2)
+
+	if !s.Add("b") || !s.Add("a") {
+		t.Errorf("Add of new key returned false")
+	}
+	if s.Add("a") {
+		t.Errorf("Add of existing key returned true")
+	}
+	if !s.Contains("a") || s.Contains("c") {
+		t.Errorf("Contains returned wrong result")
+	}
+	if s.Len() != 2 {
+		t.Errorf("Len() = %v; expected 2", s.Len())
+	}
+	if !s.Remove("a") || s.Remove("a") {
+		t.Errorf("Remove returned wrong result")
+	}
+	if s.Contains("a") {
+		t.Errorf("Removed key still present")
+	}
+}
+
+func TestSetOrderedAccess(t *testing.T) {
+	s := setOf(2, 40, 10, 30, 20, 50)
+
+	if keys := slices.Collect(s.All()); !slices.Equal(keys, []int{10, 20, 30, 40, 50}) {
+		t.Errorf("All() = %v; expected [10 20 30 40 50]", keys)
+	}
+	if k, found := s.Min(); !found || k != 10 {
+		t.Errorf("Min() = (%v, %v); expected (10, true)", k, found)
+	}
+	if k, found := s.Max(); !found || k != 50 {
+		t.Errorf("Max() = (%v, %v); expected (50, true)", k, found)
+	}
+	if k, found := s.Floor(35); !found || k != 30 {
+		t.Errorf("Floor(35) = (%v, %v); expected (30, true)", k, found)
+	}
+	if k, found := s.Ceiling(35); !found || k != 40 {
+		t.Errorf("Ceiling(35) = (%v, %v); expected (40, true)", k, found)
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	a := setOf(2, 1, 2, 3, 4, 5, 6)
+	b := setOf(3, 4, 5, 6, 7, 8)
+
+	tests := []struct {
+		name     string
+		result   *BTreeSet[int]
+		expected []int
+	}{
+		{"union", a.Union(b), []int{1, 2, 3, 4, 5, 6, 7, 8}},
+		{"intersection", a.Intersection(b), []int{4, 5, 6}},
+		{"difference", a.Difference(b), []int{1, 2, 3}},
+		{"symmetric difference", a.SymmetricDifference(b), []int{1, 2, 3, 7, 8}},
+		{"empty intersection", a.Intersection(setOf(2)), nil},
+		{"empty union", setOf(2).Union(b), []int{4, 5, 6, 7, 8}},
+	}
+
+	for _, test := range tests {
+		if keys := slices.Collect(test.result.All()); !slices.Equal(keys, test.expected) {
+			t.Errorf("%v = %v; expected %v", test.name, keys, test.expected)
+		}
+		if test.result.Len() != len(test.expected) {
+			t.Errorf("%v Len() = %v; expected %v", test.name, test.result.Len(), len(test.expected))
+		}
+	}
+}
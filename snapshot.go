@@ -0,0 +1,79 @@
+package btree
+
+import (
+	"cmp"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"slices"
+)
+
+const snapshotVersion = 1
+
+type snapshotHeader struct {
+	Version int
+	Degree  int
+	Len     int
+}
+
+type snapshotItem[K cmp.Ordered, V any] struct {
+	Key   K
+	Value V
+}
+
+/*
+Writes the degree and items of the btree to w as a gob stream, in ascending key
+order. Keys and values must be encodable by encoding/gob. Custom comparators are
+not part of the snapshot and must be passed to ReadSnapshot again. Neither are the
+nodes: ReadSnapshot rebuilds the tree by inserting the items, which may give it a
+different shape
+*/
+func (t *BTree[K, V]) WriteSnapshot(w io.Writer) error {
+	enc := gob.NewEncoder(w)
+	header := snapshotHeader{Version: snapshotVersion, Degree: t.degree, Len: t.length}
+	if err := enc.Encode(header); err != nil {
+		return fmt.Errorf("btree: writing snapshot header: %w", err)
+	}
+	for k, v := range t.All() {
+		if err := enc.Encode(snapshotItem[K, V]{Key: k, Value: v}); err != nil {
+			return fmt.Errorf("btree: writing snapshot item %v: %w", k, err)
+		}
+	}
+	return nil
+}
+
+/*
+Reads a btree written by WriteSnapshot. opts configure the restored tree like those
+of New, except that the degree is taken from the snapshot. Returns an error if the
+snapshot is truncated, has an unknown version, or its keys are not in ascending
+order under the comparator of the restored tree
+*/
+func ReadSnapshot[K cmp.Ordered, V any](r io.Reader, opts ...Option) (*BTree[K, V], error) {
+	dec := gob.NewDecoder(r)
+	var header snapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("btree: reading snapshot header: %w", err)
+	}
+	if header.Version != snapshotVersion {
+		return nil, fmt.Errorf("btree: unsupported snapshot version %d", header.Version)
+	}
+	if header.Degree < 2 || header.Len < 0 {
+		return nil, fmt.Errorf("btree: invalid snapshot header %+v", header)
+	}
+
+	t, err := New[K, V](slices.Concat(opts, []Option{WithDegree(header.Degree)})...)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range header.Len {
+		var item snapshotItem[K, V]
+		if err := dec.Decode(&item); err != nil {
+			return nil, fmt.Errorf("btree: reading snapshot item %d of %d: %w", idx+1, header.Len, err)
+		}
+		if maxKey, _, found := t.Max(); found && t.cmp(item.Key, maxKey) <= 0 {
+			return nil, fmt.Errorf("btree: snapshot key %v follows %v", item.Key, maxKey)
+		}
+		t.Insert(item.Key, item.Value)
+	}
+	return t, nil
+}
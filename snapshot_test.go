@@ -0,0 +1,124 @@
+package btree
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, 10, 1000} {
+		btree := NewBtree[string, int](4)
+		for i := range size {
+			btree.Insert(strconv.Itoa(i), i)
+		}
+
+		var buf bytes.Buffer
+		if err := btree.WriteSnapshot(&buf); err != nil {
+			t.Fatal(err)
+		}
+		restored, err := ReadSnapshot[string, int](&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if restored.degree != 4 || restored.Len() != size {
+			t.Errorf("Restored degree %v and %v items; expected 4 and %v", restored.degree, restored.Len(), size)
+		}
+		if err := restored.Validate(); err != nil {
+			t.Error(err)
+		}
+		for k, v := range btree.All() {
+			if got, found := restored.Get(k); !found || got != v {
+				t.Errorf("Get(%v) = (%v, %v); expected (%v, true)", k, got, found, v)
+			}
+		}
+	}
+}
+
+func TestSnapshotTruncated(t *testing.T) {
+	btree := NewBtree[int, int](2)
+	for i := range 100 {
+		btree.Insert(i, i)
+	}
+	var buf bytes.Buffer
+	if err := btree.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	truncated := buf.Bytes()[:buf.Len()/2]
+	if _, err := ReadSnapshot[int, int](bytes.NewReader(truncated)); err == nil {
+		t.Errorf("ReadSnapshot() of truncated snapshot succeeded")
+	}
+	if _, err := ReadSnapshot[int, int](bytes.NewReader(nil)); err == nil {
+		t.Errorf("ReadSnapshot() of empty input succeeded")
+	}
+}
+
+func TestSnapshotTypeMismatch(t *testing.T) {
+	btree := NewBtree[int, int](2)
+	btree.Insert(1, 1)
+	var buf bytes.Buffer
+	if err := btree.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadSnapshot[string, int](&buf); err == nil {
+		t.Errorf("ReadSnapshot() with wrong key type succeeded")
+	}
+}
+
+func TestSnapshotUnsortedKeys(t *testing.T) {
+	// A descending tree writes its keys in descending order, which a natural
+	// ordering cannot restore
+	descending, err := New[int, int](WithDegree(2), WithComparator(func(a, b int) int { return b - a }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 10 {
+		descending.Insert(i, i)
+	}
+	var buf bytes.Buffer
+	if err := descending.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadSnapshot[int, int](&buf); err == nil {
+		t.Errorf("ReadSnapshot() of unsorted keys succeeded")
+	}
+}
+
+func TestSnapshotComparatorRoundTrip(t *testing.T) {
+	reversed := WithComparator(func(a, b int) int { return cmp.Compare(b, a) })
+	descending, err := New[int, int](WithDegree(2), reversed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 100 {
+		descending.Insert(i, i)
+	}
+	var buf bytes.Buffer
+	if err := descending.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := ReadSnapshot[int, int](&buf, reversed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.Validate(); err != nil {
+		t.Error(err)
+	}
+	var expected, keys []int
+	for k := range descending.All() {
+		expected = append(expected, k)
+	}
+	for k := range restored.All() {
+		keys = append(keys, k)
+	}
+	if !slices.Equal(keys, expected) {
+		t.Errorf("Restored keys %v; expected %v", keys, expected)
+	}
+}
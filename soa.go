@@ -0,0 +1,351 @@
+package btree
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+/*
+SoABTree is a btree whose nodes keep keys and values in separate slices. Searching a
+node only touches its keys, which keeps lookups cache friendly when values are large
+*/
+type SoABTree[K cmp.Ordered, V any] struct {
+	degree int
+	root   *soaNode[K, V]
+	length int
+}
+
+type soaNode[K cmp.Ordered, V any] struct {
+	keys     []K
+	values   []V
+	children []*soaNode[K, V]
+}
+
+func NewSoABtree[K cmp.Ordered, V any](degree int) *SoABTree[K, V] {
+	if degree < 2 {
+		panic("Invalid degree. Must be larger than 1")
+	}
+	return &SoABTree[K, V]{degree: degree}
+}
+
+func (t *SoABTree[K, V]) minItems() int {
+	return t.degree - 1
+}
+
+func (t *SoABTree[K, V]) maxItems() int {
+	return t.degree*2 - 1
+}
+
+func (t *SoABTree[K, V]) newNode() *soaNode[K, V] {
+	return &soaNode[K, V]{
+		keys:     make([]K, 0, t.maxItems()),
+		values:   make([]V, 0, t.maxItems()),
+		children: make([]*soaNode[K, V], 0, t.degree*2),
+	}
+}
+
+func (n *soaNode[K, V]) isLeaf() bool {
+	return len(n.children) == 0
+}
+
+// Implements nodeOps
+func (t *SoABTree[K, V]) nodeSize(n *soaNode[K, V]) int {
+	return len(n.keys)
+}
+
+func (t *SoABTree[K, V]) nodeIsLeaf(n *soaNode[K, V]) bool {
+	return n.isLeaf()
+}
+
+func (t *SoABTree[K, V]) nodeChild(n *soaNode[K, V], i int) *soaNode[K, V] {
+	return n.children[i]
+}
+
+/*
+Returns the index where key k should be inserted in keys, and whether it was found
+there. This is a branchless binary search: the range is halved by the same amount
+whatever the outcome of each comparison, which only decides through a mask whether
+the base moves. Lookups therefore do not stall on mispredicted branches, which a
+plain binary search suffers on about half of its comparisons
+*/
+func searchKeys[K cmp.Ordered](keys []K, k K) (int, bool) {
+	if len(keys) == 0 {
+		return 0, false
+	}
+	base, n := 0, len(keys)
+	for n > 1 {
+		half := n / 2
+		base += half & -boolToInt(keys[base+half] < k)
+		n -= half
+	}
+	base += boolToInt(keys[base] < k)
+	return base, base < len(keys) && keys[base] == k
+}
+
+// Returns 1 for true and 0 for false, which the compiler does without a branch
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+/*
+Returns the number of items in the btree
+*/
+func (t *SoABTree[K, V]) Len() int {
+	return t.length
+}
+
+/*
+Attempt to get item with key k. Success is indicated by returned bool
+*/
+func (t *SoABTree[K, V]) Get(k K) (V, bool) {
+	for n := t.root; n != nil; {
+		idx, found := searchKeys(n.keys, k)
+		if found {
+			return n.values[idx], true
+		}
+		if n.isLeaf() {
+			break
+		}
+		n = n.children[idx]
+	}
+	var zeroVal V
+	return zeroVal, false
+}
+
+/*
+Returns an iterator over all key, value pairs in ascending key order.
+The tree must not be modified while iterating
+*/
+func (t *SoABTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if t.root != nil {
+			t.ascend(t.root, yield)
+		}
+	}
+}
+
+func (t *SoABTree[K, V]) ascend(n *soaNode[K, V], yield func(K, V) bool) bool {
+	for idx, k := range n.keys {
+		if !n.isLeaf() && !t.ascend(n.children[idx], yield) {
+			return false
+		}
+		if !yield(k, n.values[idx]) {
+			return false
+		}
+	}
+	if n.isLeaf() {
+		return true
+	}
+	return t.ascend(n.children[len(n.children)-1], yield)
+}
+
+/*
+Splits a node n. Returns the promoted key and value, and the new node
+*/
+func (t *SoABTree[K, V]) split(n *soaNode[K, V]) (K, V, *soaNode[K, V]) {
+	median := len(n.keys) / 2
+	promotedKey, promotedValue := n.keys[median], n.values[median]
+
+	newNode := t.newNode()
+	newNode.keys, n.keys = moveTail(newNode.keys, n.keys, median+1)
+	newNode.values, n.values = moveTail(newNode.values, n.values, median+1)
+	n.keys = slices.Delete(n.keys, median, median+1)
+	n.values = slices.Delete(n.values, median, median+1)
+
+	if !n.isLeaf() {
+		newNode.children, n.children = moveTail(newNode.children, n.children, median+1)
+	}
+
+	return promotedKey, promotedValue, newNode
+}
+
+/*
+Insert key,value pair into btree
+*/
+func (t *SoABTree[K, V]) Insert(k K, v V) {
+	// Initialize btree if required
+	if t.root == nil {
+		t.root = t.newNode()
+		t.root.keys = append(t.root.keys, k)
+		t.root.values = append(t.root.values, v)
+		t.length = 1
+		return
+	}
+	if len(t.root.keys) >= t.maxItems() {
+		promotedKey, promotedValue, splitNode := t.split(t.root)
+		newRoot := t.newNode()
+		newRoot.keys = append(newRoot.keys, promotedKey)
+		newRoot.values = append(newRoot.values, promotedValue)
+		newRoot.children = append(newRoot.children, t.root, splitNode)
+		t.root = newRoot
+	}
+
+	if t.insert(k, v, t.root) {
+		t.length++
+	}
+}
+
+/*
+Insert key, value pair into subtree rooted at n. Returns whether a new item was
+added, as opposed to an existing one being replaced
+*/
+func (t *SoABTree[K, V]) insert(k K, v V, n *soaNode[K, V]) bool {
+	idx, found := searchKeys(n.keys, k)
+
+	if found {
+		n.values[idx] = v
+		return false
+	}
+
+	if n.isLeaf() {
+		n.keys = slices.Insert(n.keys, idx, k)
+		n.values = slices.Insert(n.values, idx, v)
+		return true
+	}
+
+	if len(n.children[idx].keys) >= t.maxItems() {
+		promotedKey, promotedValue, splitNode := t.split(n.children[idx])
+		n.keys = slices.Insert(n.keys, idx, promotedKey)
+		n.values = slices.Insert(n.values, idx, promotedValue)
+		n.children = slices.Insert(n.children, idx+1, splitNode)
+
+		// The split might change our direction
+		if k > promotedKey {
+			idx++
+		} else if k == promotedKey {
+			n.values[idx] = v
+			return false
+		}
+	}
+
+	return t.insert(k, v, n.children[idx])
+}
+
+/*
+Delete item with key k from btree. Returns whether the key was found
+*/
+func (t *SoABTree[K, V]) Delete(k K) bool {
+	if t.root == nil {
+		return false
+	}
+
+	found := t.delete(k, t.root)
+	t.root, _ = shrinkRoot(t, t.root)
+
+	if found {
+		t.length--
+	}
+	return found
+}
+
+/*
+Delete item with key k from subtree rooted at n, which has more than the minimum
+number of items unless it is the root. Returns whether key was found
+*/
+func (t *SoABTree[K, V]) delete(k K, n *soaNode[K, V]) bool {
+	idx, found := searchKeys(n.keys, k)
+
+	if n.isLeaf() {
+		if found {
+			n.keys = slices.Delete(n.keys, idx, idx+1)
+			n.values = slices.Delete(n.values, idx, idx+1)
+		}
+		return found
+	}
+
+	if found {
+		// Replace the key with its predecessor or successor if either child can
+		// spare an item, otherwise merge the children around it
+		if len(n.children[idx].keys) > t.minItems() {
+			n.keys[idx], n.values[idx] = t.popMax(n.children[idx])
+		} else if len(n.children[idx+1].keys) > t.minItems() {
+			n.keys[idx], n.values[idx] = t.popMin(n.children[idx+1])
+		} else {
+			t.merge(n, idx)
+			t.delete(k, n.children[idx])
+		}
+		return true
+	}
+
+	return t.delete(k, deletePathChild(t, n, idx))
+}
+
+/*
+Pop the max item of the subtree rooted at n, assuming that n has more than min items
+*/
+func (t *SoABTree[K, V]) popMax(n *soaNode[K, V]) (K, V) {
+	n = descendToMax(t, n)
+	last := len(n.keys) - 1
+	k, v := n.keys[last], n.values[last]
+	n.keys = slices.Delete(n.keys, last, last+1)
+	n.values = slices.Delete(n.values, last, last+1)
+	return k, v
+}
+
+/*
+Pop the min item of the subtree rooted at n, assuming that n has more than min items
+*/
+func (t *SoABTree[K, V]) popMin(n *soaNode[K, V]) (K, V) {
+	n = descendToMin(t, n)
+	k, v := n.keys[0], n.values[0]
+	n.keys = slices.Delete(n.keys, 0, 1)
+	n.values = slices.Delete(n.values, 0, 1)
+	return k, v
+}
+
+/*
+Rebalances child at index i of node n. Returns a pointer to child i
+or its left sibling, if child i got merged into it
+*/
+func (t *SoABTree[K, V]) rebalance(n *soaNode[K, V], i int) *soaNode[K, V] {
+	return rebalanceChild(t, n, i)
+}
+
+// Steals an item from the left sibling of child at index i of node n
+func (t *SoABTree[K, V]) stealFromLeftSibling(n *soaNode[K, V], i int) {
+	child, sibling := n.children[i], n.children[i-1]
+	last := len(sibling.keys) - 1
+	child.keys = slices.Insert(child.keys, 0, n.keys[i-1])
+	child.values = slices.Insert(child.values, 0, n.values[i-1])
+	n.keys[i-1], n.values[i-1] = sibling.keys[last], sibling.values[last]
+	sibling.keys = slices.Delete(sibling.keys, last, last+1)
+	sibling.values = slices.Delete(sibling.values, last, last+1)
+	if !sibling.isLeaf() {
+		lastChild := len(sibling.children) - 1
+		child.children = slices.Insert(child.children, 0, sibling.children[lastChild])
+		sibling.children = slices.Delete(sibling.children, lastChild, lastChild+1)
+	}
+}
+
+// Steals an item from the right sibling of child at index i of node n
+func (t *SoABTree[K, V]) stealFromRightSibling(n *soaNode[K, V], i int) {
+	child, sibling := n.children[i], n.children[i+1]
+	child.keys = append(child.keys, n.keys[i])
+	child.values = append(child.values, n.values[i])
+	n.keys[i], n.values[i] = sibling.keys[0], sibling.values[0]
+	sibling.keys = slices.Delete(sibling.keys, 0, 1)
+	sibling.values = slices.Delete(sibling.values, 0, 1)
+	if !sibling.isLeaf() {
+		child.children = append(child.children, sibling.children[0])
+		sibling.children = slices.Delete(sibling.children, 0, 1)
+	}
+}
+
+// Merge child at index i of node n, with child at index i+1
+func (t *SoABTree[K, V]) merge(n *soaNode[K, V], i int) {
+	child, sibling := n.children[i], n.children[i+1]
+
+	child.keys = append(child.keys, n.keys[i])
+	child.keys = append(child.keys, sibling.keys...)
+	child.values = append(child.values, n.values[i])
+	child.values = append(child.values, sibling.values...)
+	child.children = append(child.children, sibling.children...)
+
+	n.keys = slices.Delete(n.keys, i, i+1)
+	n.values = slices.Delete(n.values, i, i+1)
+	n.children = slices.Delete(n.children, i+1, i+2)
+}
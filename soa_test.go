@@ -0,0 +1,119 @@
+package btree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// Checks fill, ordering and depth invariants of the subtree rooted at n. Returns the depth of its leaves
+func (tree *SoABTree[K, V]) checkNode(t *testing.T, n *soaNode[K, V], isRoot bool) int {
+	if len(n.keys) != len(n.values) {
+		t.Fatalf("Node has %v keys but %v values", len(n.keys), len(n.values))
+	}
+	if len(n.keys) > tree.maxItems() || (!isRoot && len(n.keys) < tree.minItems()) {
+		t.Fatalf("Node has %v keys: %v", len(n.keys), n.keys)
+	}
+	if !slices.IsSorted(n.keys) {
+		t.Fatalf("Node keys are not sorted: %v", n.keys)
+	}
+	if n.isLeaf() {
+		return 0
+	}
+	if len(n.children) != len(n.keys)+1 {
+		t.Fatalf("Node has %v keys and %v children", len(n.keys), len(n.children))
+	}
+
+	depth := -1
+	for _, child := range n.children {
+		childDepth := tree.checkNode(t, child, false)
+		if depth != -1 && childDepth != depth {
+			t.Fatalf("Leaves at different depths below %v", n.keys)
+		}
+		depth = childDepth
+	}
+	return depth + 1
+}
+
+func TestSearchKeys(t *testing.T) {
+	// Every length up to that of the largest node tested, as the search halves odd and even ranges differently
+	for size := range 40 {
+		keys := make([]int, size)
+		for i := range keys {
+			keys[i] = 2*i + 1
+		}
+		for k := 0; k <= 2*size+1; k++ {
+			idx, found := searchKeys(keys, k)
+			expectedIdx, expectedFound := slices.BinarySearch(keys, k)
+			if idx != expectedIdx || found != expectedFound {
+				t.Errorf("searchKeys(%d) = (%d, %v); expected (%d, %v)", k, idx, found, expectedIdx, expectedFound)
+			}
+		}
+	}
+}
+
+func TestSoABTreeRandomOps(t *testing.T) {
+	random := rand.New(rand.NewPCG(424242, 1024))
+
+	for d := 2; d < 12; d++ {
+		t.Run(fmt.Sprintf("Random ops at degree %v", d), func(t *testing.T) {
+			tree := NewSoABtree[int, int](d)
+			expected := make(map[int]int)
+
+			for i := range 5000 {
+				key := random.IntN(300)
+				switch random.IntN(3) {
+				case 0, 1:
+					tree.Insert(key, i)
+					expected[key] = i
+				case 2:
+					_, expectedFound := expected[key]
+					if found := tree.Delete(key); found != expectedFound {
+						t.Fatalf("Delete(%d) = %v; expected %v", key, found, expectedFound)
+					}
+					delete(expected, key)
+				}
+
+				if tree.root != nil {
+					tree.checkNode(t, tree.root, true)
+				}
+				if tree.Len() != len(expected) {
+					t.Fatalf("Len() = %v; expected %v", tree.Len(), len(expected))
+				}
+			}
+
+			for k, v := range expected {
+				if got, found := tree.Get(k); !found || got != v {
+					t.Errorf("Get(%d) = (%v, %v); expected (%v, true)", k, got, found, v)
+				}
+			}
+			if _, found := tree.Get(-1); found {
+				t.Errorf("Get(-1) found a missing key")
+			}
+
+			var keys []int
+			for k := range tree.All() {
+				keys = append(keys, k)
+			}
+			if !slices.IsSorted(keys) || len(keys) != len(expected) {
+				t.Errorf("All() = %v; expected %v sorted keys", keys, len(expected))
+			}
+		})
+	}
+}
+
+func TestSoABTreeDeleteAll(t *testing.T) {
+	tree := NewSoABtree[int, string](3)
+	for i := range 1000 {
+		tree.Insert(i, fmt.Sprint(i))
+	}
+	for i := range 1000 {
+		if !tree.Delete(i) {
+			t.Fatalf("Delete(%d) did not find key", i)
+		}
+	}
+	if tree.root != nil || tree.Len() != 0 {
+		t.Errorf("Tree not empty after deleting every key")
+	}
+}
@@ -0,0 +1,80 @@
+package btree
+
+import (
+	"unsafe"
+)
+
+// LevelStats describes the nodes at one depth of a btree
+type LevelStats struct {
+	Nodes int
+	Items int
+	// Average fraction of the maximum number of items held by the nodes
+	FillFactor float64
+	// Number of nodes at this level per fill factor decile, as Stats.FillHistogram
+	FillHistogram [10]int
+}
+
+// Stats describes the shape and size of a btree
+type Stats struct {
+	Height int
+	Nodes  int
+	Leaves int
+	Items  int
+	// Average fraction of the maximum number of items held by each node
+	FillFactor float64
+	// Per level statistics, starting at the root
+	Levels []LevelStats
+	// Number of nodes per fill factor decile. A completely full node falls in the last bucket
+	FillHistogram [10]int
+	// Estimated bytes used by the tree, based on the capacity of the node slices.
+	// Memory referenced by keys and values is not included
+	MemoryBytes int
+}
+
+/*
+Collects statistics about the btree. This walks every node
+*/
+func (t *BTree[K, V]) Stats() Stats {
+	stats := Stats{
+		MemoryBytes: int(unsafe.Sizeof(*t)),
+	}
+	if t.root != nil {
+		t.collectStats(t.root, 0, &stats)
+	}
+
+	if stats.Nodes > 0 {
+		stats.FillFactor = float64(stats.Items) / float64(stats.Nodes*t.maxItems())
+	}
+	for idx := range stats.Levels {
+		level := &stats.Levels[idx]
+		level.FillFactor = float64(level.Items) / float64(level.Nodes*t.maxItems())
+	}
+	stats.Height = len(stats.Levels)
+	return stats
+}
+
+func (t *BTree[K, V]) collectStats(n *Node[K, V], depth int, stats *Stats) {
+	if depth == len(stats.Levels) {
+		stats.Levels = append(stats.Levels, LevelStats{})
+	}
+	level := &stats.Levels[depth]
+	bucket := min(len(n.items)*len(stats.FillHistogram)/t.maxItems(), len(stats.FillHistogram)-1)
+	level.Nodes++
+	level.Items += len(n.items)
+	level.FillHistogram[bucket]++
+
+	stats.Nodes++
+	stats.Items += len(n.items)
+	stats.FillHistogram[bucket]++
+	stats.MemoryBytes += int(unsafe.Sizeof(*n)) +
+		cap(n.children)*int(unsafe.Sizeof((*Node[K, V])(nil))) +
+		cap(n.items)*int(unsafe.Sizeof(Item[K, V]{}))
+
+	if n.isLeaf() {
+		stats.Leaves++
+		return
+	}
+	for _, child := range n.children {
+		t.collectStats(child, depth+1, stats)
+	}
+}
@@ -0,0 +1,101 @@
+package btree
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+func TestStatsEmpty(t *testing.T) {
+	stats := NewBtree[int, int](3).Stats()
+	if stats.Height != 0 || stats.Nodes != 0 || stats.Items != 0 || stats.FillFactor != 0 {
+		t.Errorf("Stats() of empty tree = %+v", stats)
+	}
+}
+
+func TestStatsShape(t *testing.T) {
+	// Root [20] with leaves [10] and [30 40] at degree 2
+	btree := NewBtree[int, int](2)
+	for _, k := range []int{10, 20, 30, 40} {
+		btree.Insert(k, k)
+	}
+
+	stats := btree.Stats()
+	if stats.Height != 2 || stats.Nodes != 3 || stats.Leaves != 2 || stats.Items != 4 {
+		t.Errorf("Stats() = %+v; expected height 2, 3 nodes, 2 leaves and 4 items", stats)
+	}
+
+	expectedLevels := []LevelStats{
+		{Nodes: 1, Items: 1, FillFactor: 1.0 / 3, FillHistogram: [10]int{3: 1}},
+		{Nodes: 2, Items: 3, FillFactor: 3.0 / 6, FillHistogram: [10]int{3: 1, 6: 1}},
+	}
+	for idx, expected := range expectedLevels {
+		if stats.Levels[idx] != expected {
+			t.Errorf("Levels[%d] = %+v; expected %+v", idx, stats.Levels[idx], expected)
+		}
+	}
+	if stats.FillFactor != 4.0/9 {
+		t.Errorf("FillFactor = %v; expected %v", stats.FillFactor, 4.0/9)
+	}
+
+	// Fill factors of 1/3, 1/3 and 2/3
+	expectedHistogram := [10]int{3: 2, 6: 1}
+	if stats.FillHistogram != expectedHistogram {
+		t.Errorf("FillHistogram = %v; expected %v", stats.FillHistogram, expectedHistogram)
+	}
+
+	nodeBytes := int(unsafe.Sizeof(Node[int, int]{})) + 4*int(unsafe.Sizeof(&Node[int, int]{})) + 3*int(unsafe.Sizeof(Item[int, int]{}))
+	if stats.MemoryBytes < 3*nodeBytes {
+		t.Errorf("MemoryBytes = %v; expected at least %v", stats.MemoryBytes, 3*nodeBytes)
+	}
+}
+
+func TestStatsConsistent(t *testing.T) {
+	for d := 2; d < 10; d++ {
+		t.Run(fmt.Sprintf("Stats at degree %v", d), func(t *testing.T) {
+			btree := NewBtree[int, int](d)
+			for i := range 5000 {
+				btree.Insert(i*7919%5000, i)
+			}
+
+			stats := btree.Stats()
+			if stats.Items != btree.Len() {
+				t.Errorf("Items = %v; expected %v", stats.Items, btree.Len())
+			}
+
+			nodes, items, histogram := 0, 0, 0
+			var levelHistograms [10]int
+			for depth, level := range stats.Levels {
+				nodes += level.Nodes
+				items += level.Items
+				levelNodes := 0
+				for bucket, count := range level.FillHistogram {
+					levelNodes += count
+					levelHistograms[bucket] += count
+				}
+				if levelNodes != level.Nodes {
+					t.Errorf("Levels[%d] histogram counts %v nodes; expected %v", depth, levelNodes, level.Nodes)
+				}
+			}
+			for _, count := range stats.FillHistogram {
+				histogram += count
+			}
+			if nodes != stats.Nodes || items != stats.Items || histogram != stats.Nodes {
+				t.Errorf("Levels and histogram disagree with totals: %+v", stats)
+			}
+			if levelHistograms != stats.FillHistogram {
+				t.Errorf("Level histograms add up to %v; expected %v", levelHistograms, stats.FillHistogram)
+			}
+			// Every node but the root holds at least degree-1 items, so no non-root node is nearly empty
+			for depth, level := range stats.Levels[1:] {
+				if minBucket := (d - 1) * 10 / btree.maxItems(); slices.ContainsFunc(level.FillHistogram[:minBucket], func(c int) bool { return c > 0 }) {
+					t.Errorf("Levels[%d] histogram %v has nodes below the minimum fill", depth+1, level.FillHistogram)
+				}
+			}
+			if stats.Levels[len(stats.Levels)-1].Nodes != stats.Leaves {
+				t.Errorf("Last level has %v nodes; expected %v leaves", stats.Levels[len(stats.Levels)-1].Nodes, stats.Leaves)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package btree
+
+import (
+	"cmp"
+	"expvar"
+)
+
+/*
+Tracer receives the structural changes a btree makes while inserting and deleting.
+Key slices passed to a Tracer are copies and may be retained
+*/
+type Tracer[K cmp.Ordered] interface {
+	// A full node was split. promoted moved up to the parent, left and right are the keys of the two halves
+	Split(promoted K, left []K, right []K)
+	// Two siblings were merged around separator, which moved down from the parent into merged
+	Merge(separator K, merged []K)
+	// An item rotated from the left sibling through the parent into child. separator is the new parent key
+	StealFromLeft(separator K, child []K, sibling []K)
+	// An item rotated from the right sibling through the parent into child. separator is the new parent key
+	StealFromRight(separator K, child []K, sibling []K)
+	// The child at index child of parent is about to be refilled by stealing or merging
+	Rebalance(parent []K, child int)
+	// The root was split, and the tree grew a level. root are the keys of the new root
+	GrowRoot(root []K)
+	// The root ran out of items, and the tree lost a level. root are the keys of the new root
+	ShrinkRoot(root []K)
+}
+
+/*
+Set the tracer receiving structural changes of the btree. A nil tracer disables tracing
+*/
+func (t *BTree[K, V]) SetTracer(tracer Tracer[K]) {
+	t.tracer = tracer
+}
+
+// Names of the counters maintained by CountingTracer
+const (
+	EventSplit          = "split"
+	EventMerge          = "merge"
+	EventStealFromLeft  = "steal_from_left"
+	EventStealFromRight = "steal_from_right"
+	EventRebalance      = "rebalance"
+	EventGrowRoot       = "grow_root"
+	EventShrinkRoot     = "shrink_root"
+)
+
+/*
+CountingTracer counts structural changes into an expvar.Map. Publish Counters with
+expvar.Publish to expose them
+*/
+type CountingTracer[K cmp.Ordered] struct {
+	Counters *expvar.Map
+}
+
+func NewCountingTracer[K cmp.Ordered]() *CountingTracer[K] {
+	return &CountingTracer[K]{Counters: new(expvar.Map).Init()}
+}
+
+/*
+Returns the current count of the named event
+*/
+func (c *CountingTracer[K]) Count(event string) int64 {
+	if counter, ok := c.Counters.Get(event).(*expvar.Int); ok {
+		return counter.Value()
+	}
+	return 0
+}
+
+func (c *CountingTracer[K]) Split(K, []K, []K) {
+	c.Counters.Add(EventSplit, 1)
+}
+
+func (c *CountingTracer[K]) Merge(K, []K) {
+	c.Counters.Add(EventMerge, 1)
+}
+
+func (c *CountingTracer[K]) StealFromLeft(K, []K, []K) {
+	c.Counters.Add(EventStealFromLeft, 1)
+}
+
+func (c *CountingTracer[K]) StealFromRight(K, []K, []K) {
+	c.Counters.Add(EventStealFromRight, 1)
+}
+
+func (c *CountingTracer[K]) Rebalance([]K, int) {
+	c.Counters.Add(EventRebalance, 1)
+}
+
+func (c *CountingTracer[K]) GrowRoot([]K) {
+	c.Counters.Add(EventGrowRoot, 1)
+}
+
+func (c *CountingTracer[K]) ShrinkRoot([]K) {
+	c.Counters.Add(EventShrinkRoot, 1)
+}
@@ -0,0 +1,120 @@
+package btree
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+type recordingTracer struct {
+	events []string
+}
+
+func (r *recordingTracer) Split(promoted int, left, right []int) {
+	r.events = append(r.events, fmt.Sprintf("split %v %v %v", promoted, left, right))
+}
+
+func (r *recordingTracer) Merge(separator int, merged []int) {
+	r.events = append(r.events, fmt.Sprintf("merge %v %v", separator, merged))
+}
+
+func (r *recordingTracer) StealFromLeft(separator int, child, sibling []int) {
+	r.events = append(r.events, fmt.Sprintf("steal left %v %v %v", separator, child, sibling))
+}
+
+func (r *recordingTracer) StealFromRight(separator int, child, sibling []int) {
+	r.events = append(r.events, fmt.Sprintf("steal right %v %v %v", separator, child, sibling))
+}
+
+func (r *recordingTracer) Rebalance(parent []int, child int) {
+	r.events = append(r.events, fmt.Sprintf("rebalance %v %v", parent, child))
+}
+
+func (r *recordingTracer) GrowRoot(root []int) {
+	r.events = append(r.events, fmt.Sprintf("grow %v", root))
+}
+
+func (r *recordingTracer) ShrinkRoot(root []int) {
+	r.events = append(r.events, fmt.Sprintf("shrink %v", root))
+}
+
+func TestTracerEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		inserts  []int
+		deletes  []int
+		expected []string
+	}{
+		{
+			name:     "grow",
+			inserts:  []int{10, 20, 30, 40},
+			expected: []string{"split 20 [10] [30]", "grow [20]"},
+		},
+		{
+			name:     "merge and shrink",
+			inserts:  []int{10, 20, 30, 40},
+			deletes:  []int{40, 10},
+			expected: []string{"split 20 [10] [30]", "grow [20]", "rebalance [20] 0", "merge 20 [10 20 30]", "shrink [20 30]"},
+		},
+		{
+			name:     "steal from right",
+			inserts:  []int{10, 20, 30, 40},
+			deletes:  []int{10},
+			expected: []string{"split 20 [10] [30]", "grow [20]", "rebalance [20] 0", "steal right 30 [10 20] [40]"},
+		},
+		{
+			name:     "steal from left",
+			inserts:  []int{10, 20, 30, 5},
+			deletes:  []int{30},
+			expected: []string{"split 20 [10] [30]", "grow [20]", "rebalance [20] 1", "steal left 10 [20 30] [5]"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracer := &recordingTracer{}
+			btree := NewBtree[int, int](2)
+			btree.SetTracer(tracer)
+			for _, k := range test.inserts {
+				btree.Insert(k, k)
+			}
+			for _, k := range test.deletes {
+				btree.Delete(k)
+			}
+
+			if !slices.Equal(tracer.events, test.expected) {
+				t.Errorf("Events = %q; expected %q", tracer.events, test.expected)
+			}
+		})
+	}
+}
+
+func TestCountingTracer(t *testing.T) {
+	tracer := NewCountingTracer[int]()
+	btree := NewBtree[int, int](2)
+	btree.SetTracer(tracer)
+
+	for i := range 1000 {
+		btree.Insert(i, i)
+	}
+	splits, grows := tracer.Count(EventSplit), tracer.Count(EventGrowRoot)
+	if splits == 0 || grows == 0 {
+		t.Errorf("Counted %v splits and %v root grows; expected both to be positive", splits, grows)
+	}
+	if height := int64(len(btree.Stats().Levels)); grows != height-1 {
+		t.Errorf("Counted %v root grows; expected %v", grows, height-1)
+	}
+
+	for i := range 1000 {
+		btree.Delete(i)
+	}
+	if tracer.Count(EventMerge) == 0 || tracer.Count(EventRebalance) == 0 {
+		t.Errorf("Counters after deleting everything: %v", tracer.Counters)
+	}
+	if tracer.Count(EventShrinkRoot) != grows+1 {
+		t.Errorf("Counted %v root shrinks; expected %v", tracer.Count(EventShrinkRoot), grows+1)
+	}
+	if tracer.Count("unknown") != 0 {
+		t.Errorf("Unknown event has a count")
+	}
+}
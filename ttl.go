@@ -0,0 +1,212 @@
+package btree
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock reports the current time. TTLBTree uses it to decide when entries expire
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// TTLBTree is a BTree whose entries can expire. It is safe for concurrent use
+type TTLBTree[K cmp.Ordered, V any] struct {
+	mu      sync.Mutex
+	clock   Clock
+	entries *BTree[K, ttlEntry[V]]
+	// Secondary index from expiry time in unix nanoseconds to the keys expiring then
+	expiry *BTree[int64, []K]
+}
+
+type ttlEntry[V any] struct {
+	value V
+	// Unix nanoseconds at which the entry expires. Zero means never
+	expires int64
+}
+
+/*
+Creates a TTLBTree of the given degree. If clock is nil, the system clock is used
+*/
+func NewTTLBtree[K cmp.Ordered, V any](degree int, clock Clock) *TTLBTree[K, V] {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &TTLBTree[K, V]{
+		clock:   clock,
+		entries: NewBtree[K, ttlEntry[V]](degree),
+		expiry:  NewBtree[int64, []K](degree),
+	}
+}
+
+func (e ttlEntry[V]) expired(now int64) bool {
+	return e.expires != 0 && e.expires <= now
+}
+
+/*
+Insert key, value pair which never expires. Replaces any existing entry and its TTL
+*/
+func (t *TTLBTree[K, V]) Insert(k K, v V) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.insert(k, ttlEntry[V]{value: v})
+}
+
+/*
+Insert key, value pair which expires once ttl has passed. Replaces any existing entry and its TTL
+*/
+func (t *TTLBTree[K, V]) InsertWithTTL(k K, v V, ttl time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	expires := t.clock.Now().Add(ttl).UnixNano()
+	t.insert(k, ttlEntry[V]{value: v, expires: expires})
+}
+
+func (t *TTLBTree[K, V]) insert(k K, entry ttlEntry[V]) {
+	if old, found := t.entries.Replace(k, entry); found && old.expires != 0 {
+		t.unindex(k, old.expires)
+	}
+	if entry.expires != 0 {
+		t.expiry.Upsert(entry.expires, func(keys []K, exists bool) []K {
+			return append(keys, k)
+		})
+	}
+}
+
+/*
+Attempt to get a live item with key k. Expired entries are reported as missing
+*/
+func (t *TTLBTree[K, V]) Get(k K) (V, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, found := t.entries.Get(k)
+	if !found || entry.expired(t.clock.Now().UnixNano()) {
+		var zeroVal V
+		return zeroVal, false
+	}
+	return entry.value, true
+}
+
+/*
+Delete item with key k. Returns whether a live entry was removed
+*/
+func (t *TTLBTree[K, V]) Delete(k K) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, found := t.entries.Get(k)
+	if !found {
+		return false
+	}
+	t.entries.Delete(k)
+	if entry.expires != 0 {
+		t.unindex(k, entry.expires)
+	}
+	return !entry.expired(t.clock.Now().UnixNano())
+}
+
+/*
+Returns an iterator over all live key, value pairs in ascending key order. The
+tree is locked while iterating, so yield must not call back into it
+*/
+func (t *TTLBTree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		now := t.clock.Now().UnixNano()
+		for k, entry := range t.entries.All() {
+			if entry.expired(now) {
+				continue
+			}
+			if !yield(k, entry.value) {
+				return
+			}
+		}
+	}
+}
+
+/*
+Removes up to budget expired entries, oldest first, and returns how many were
+removed. A budget of zero or less removes every expired entry
+*/
+func (t *TTLBTree[K, V]) Sweep(budget int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.clock.Now().UnixNano()
+
+	// Collect first, as the tree must not be modified while iterating it
+	var due []K
+	for expires, keys := range t.expiry.All() {
+		if expires > now {
+			break
+		}
+		due = append(due, keys...)
+		if budget > 0 && len(due) >= budget {
+			due = due[:budget]
+			break
+		}
+	}
+
+	for _, k := range due {
+		entry, _ := t.entries.Get(k)
+		t.entries.Delete(k)
+		t.unindex(k, entry.expires)
+	}
+	return len(due)
+}
+
+/*
+Starts a goroutine which calls Sweep(budget) every interval. The returned
+function stops the goroutine and waits for it to exit
+*/
+func (t *TTLBTree[K, V]) StartSweeper(interval time.Duration, budget int) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.Sweep(budget)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-exited
+		})
+	}
+}
+
+// Removes key k from the expiry index bucket for the given time
+func (t *TTLBTree[K, V]) unindex(k K, expires int64) {
+	keys, found := t.expiry.Get(expires)
+	if !found {
+		return
+	}
+	idx := slices.Index(keys, k)
+	if idx == -1 {
+		return
+	}
+	keys = slices.Delete(keys, idx, idx+1)
+	if len(keys) == 0 {
+		t.expiry.Delete(expires)
+	} else {
+		t.expiry.Insert(expires, keys)
+	}
+}
@@ -0,0 +1,182 @@
+package btree
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1_700_000_000, 0)}
+}
+
+func TestTTLGet(t *testing.T) {
+	clock := newFakeClock()
+	tree := NewTTLBtree[int, string](3, clock)
+
+	tree.InsertWithTTL(1, "one", time.Second)
+	tree.InsertWithTTL(2, "two", time.Minute)
+	tree.Insert(3, "three")
+
+	if val, found := tree.Get(1); !found || val != "one" {
+		t.Errorf("Get(1) = (%v, %v); expected (one, true)", val, found)
+	}
+
+	clock.Advance(time.Second)
+
+	tests := []struct {
+		key   int
+		found bool
+	}{
+		{1, false},
+		{2, true},
+		{3, true},
+	}
+	for _, test := range tests {
+		if _, found := tree.Get(test.key); found != test.found {
+			t.Errorf("Get(%d) found = %v; expected %v", test.key, found, test.found)
+		}
+	}
+
+	clock.Advance(time.Hour)
+	if _, found := tree.Get(3); !found {
+		t.Errorf("Entry without TTL expired")
+	}
+}
+
+func TestTTLAllSkipsExpired(t *testing.T) {
+	clock := newFakeClock()
+	tree := NewTTLBtree[int, int](2, clock)
+
+	for i := range 20 {
+		tree.InsertWithTTL(i, i, time.Duration(i+1)*time.Second)
+	}
+	clock.Advance(10 * time.Second)
+
+	var keys []int
+	for k := range tree.All() {
+		keys = append(keys, k)
+	}
+	if len(keys) != 10 || keys[0] != 10 {
+		t.Errorf("All() = %v; expected keys 10 through 19", keys)
+	}
+}
+
+func TestTTLReinsertResetsExpiry(t *testing.T) {
+	clock := newFakeClock()
+	tree := NewTTLBtree[string, int](2, clock)
+
+	tree.InsertWithTTL("a", 1, time.Second)
+	tree.InsertWithTTL("a", 2, time.Hour)
+	clock.Advance(time.Minute)
+
+	if n := tree.Sweep(0); n != 0 {
+		t.Errorf("Sweep removed %d entries; expected 0", n)
+	}
+	if val, found := tree.Get("a"); !found || val != 2 {
+		t.Errorf("Get(a) = (%v, %v); expected (2, true)", val, found)
+	}
+
+	tree.Insert("a", 3)
+	clock.Advance(2 * time.Hour)
+	if _, found := tree.Get("a"); !found {
+		t.Errorf("Insert did not clear previous TTL")
+	}
+	if tree.expiry.root != nil {
+		t.Errorf("Expiry index not empty: %v", tree.expiry)
+	}
+}
+
+func TestTTLSweepBudget(t *testing.T) {
+	clock := newFakeClock()
+	tree := NewTTLBtree[int, int](3, clock)
+
+	for i := range 100 {
+		tree.InsertWithTTL(i, i, time.Duration(i%10+1)*time.Second)
+	}
+	clock.Advance(5 * time.Second)
+
+	// Keys with i%10 < 5 have expired
+	if n := tree.Sweep(20); n != 20 {
+		t.Errorf("Sweep(20) = %d; expected 20", n)
+	}
+	if n := tree.Sweep(0); n != 30 {
+		t.Errorf("Sweep(0) = %d; expected 30", n)
+	}
+	if n := tree.Sweep(0); n != 0 {
+		t.Errorf("Sweep(0) = %d; expected 0", n)
+	}
+
+	count := 0
+	for k := range tree.entries.All() {
+		if k%10 < 5 {
+			t.Errorf("Expired key %d was not swept", k)
+		}
+		count++
+	}
+	if count != 50 {
+		t.Errorf("%d entries left; expected 50", count)
+	}
+}
+
+func TestTTLDelete(t *testing.T) {
+	clock := newFakeClock()
+	tree := NewTTLBtree[int, int](2, clock)
+
+	tree.InsertWithTTL(1, 1, time.Second)
+	tree.InsertWithTTL(2, 2, time.Second)
+	clock.Advance(time.Second)
+
+	if tree.Delete(1) {
+		t.Errorf("Delete of expired key reported a live entry")
+	}
+	if tree.Delete(3) {
+		t.Errorf("Delete of missing key succeeded")
+	}
+	if n := tree.Sweep(0); n != 1 {
+		t.Errorf("Sweep(0) = %d; expected 1", n)
+	}
+}
+
+func TestTTLStartSweeper(t *testing.T) {
+	clock := newFakeClock()
+	tree := NewTTLBtree[int, int](2, clock)
+
+	for i := range 10 {
+		tree.InsertWithTTL(i, i, time.Second)
+	}
+	clock.Advance(time.Second)
+
+	stop := tree.StartSweeper(time.Millisecond, 0)
+	defer stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		tree.mu.Lock()
+		empty := tree.entries.root == nil
+		tree.mu.Unlock()
+		if empty {
+			stop()
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("Background sweeper did not remove expired entries")
+}
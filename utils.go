@@ -1,6 +1,7 @@
 package btree
 
 import (
+	"cmp"
 	"fmt"
 	"sort"
 	"strings"
@@ -22,6 +23,41 @@ func (s items[K, V]) find(k K) (int, bool) {
 	return idx, false
 }
 
+/*
+Like find, but orders keys with compare instead of their natural ordering
+*/
+func (s items[K, V]) findFunc(k K, compare func(a, b K) int) (int, bool) {
+	idx := sort.Search(len(s), func(i int) bool {
+		return compare(s[i].key, k) >= 0
+	})
+
+	if idx < len(s) && compare(s[idx].key, k) == 0 {
+		return idx, true
+	}
+
+	return idx, false
+}
+
+/*
+Finds key k in items using the ordering of the btree
+*/
+func (t *BTree[K, V]) find(s items[K, V], k K) (int, bool) {
+	if t.compare == nil {
+		return s.find(k)
+	}
+	return s.findFunc(k, t.compare)
+}
+
+/*
+Compares two keys using the ordering of the btree
+*/
+func (t *BTree[K, V]) cmp(a, b K) int {
+	if t.compare == nil {
+		return cmp.Compare(a, b)
+	}
+	return t.compare(a, b)
+}
+
 /*
 insertAt inserts a new item with key k and value v at the specified index i
 in the slice of items. It shifts the elements at and after index i to the
@@ -32,7 +68,7 @@ func (s *items[K, V]) insertAt(k K, v V, i int) {
 	if i < len(*s)-1 {
 		copy((*s)[i+1:], (*s)[i:])
 	}
-	(*s)[i] = Item[K, V]{k, v}
+	(*s)[i] = Item[K, V]{key: k, value: v}
 }
 
 func (s *children[K, V]) insertAt(n *Node[K, V], i int) {
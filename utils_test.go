@@ -33,6 +33,38 @@ func TestFind(t *testing.T) {
 	}
 }
 
+func TestFindFunc(t *testing.T) {
+	// Sorted in descending order
+	items := items[int, string]{
+		{key: 5, value: "five"},
+		{key: 3, value: "three"},
+		{key: 1, value: "one"},
+	}
+	descending := func(a, b int) int {
+		return b - a
+	}
+
+	tests := []struct {
+		key      int
+		expected int
+		found    bool
+	}{
+		{key: 6, expected: 0, found: false},
+		{key: 5, expected: 0, found: true},
+		{key: 4, expected: 1, found: false},
+		{key: 3, expected: 1, found: true},
+		{key: 1, expected: 2, found: true},
+		{key: 0, expected: 3, found: false},
+	}
+
+	for _, test := range tests {
+		idx, found := items.findFunc(test.key, descending)
+		if idx != test.expected || found != test.found {
+			t.Errorf("findFunc(%d) = (%d, %v); expected (%d, %v)", test.key, idx, found, test.expected, test.found)
+		}
+	}
+}
+
 func TestInsertAt(t *testing.T) {
 	testItems := items[int, string]{
 		{key: 1, value: "one"},
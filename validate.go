@@ -0,0 +1,172 @@
+package btree
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// Rule identifies a btree invariant
+type Rule int
+
+const (
+	// Keys are sorted within a node and lie between the separators of the parent
+	RuleOrdering Rule = iota
+	// Every node but the root holds between minItems and maxItems items
+	RuleFill
+	// Every leaf is at the same depth
+	RuleDepth
+	// Every internal node has exactly one more child than it has items
+	RuleChildRatio
+	// The number of items matches the length tracked by the tree
+	RuleLength
+)
+
+func (r Rule) String() string {
+	switch r {
+	case RuleOrdering:
+		return "ordering"
+	case RuleFill:
+		return "fill"
+	case RuleDepth:
+		return "depth"
+	case RuleChildRatio:
+		return "child ratio"
+	case RuleLength:
+		return "length"
+	}
+	return fmt.Sprintf("Rule(%d)", int(r))
+}
+
+// Violation describes a single broken invariant
+type Violation[K cmp.Ordered] struct {
+	// Child indices leading from the root to the offending node. Empty for the root
+	Path []int
+	Rule Rule
+	// The keys involved in the violation
+	Keys    []K
+	Message string
+}
+
+func (v Violation[K]) String() string {
+	return fmt.Sprintf("node %v: %v: %v (keys %v)", v.Path, v.Rule, v.Message, v.Keys)
+}
+
+// ValidationError lists every invariant violation found by Validate
+type ValidationError[K cmp.Ordered] struct {
+	Violations []Violation[K]
+}
+
+func (e *ValidationError[K]) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "btree: %d invariant violation(s)", len(e.Violations))
+	for _, v := range e.Violations {
+		sb.WriteString("; ")
+		sb.WriteString(v.String())
+	}
+	return sb.String()
+}
+
+/*
+Checks every invariant of the btree. Returns nil if the tree is valid, otherwise a
+*ValidationError listing each violation
+*/
+func (t *BTree[K, V]) Validate() error {
+	v := validator[K, V]{tree: t, leafDepth: -1}
+	if t.root != nil {
+		v.checkNode(t.root, nil, nil, nil)
+	}
+	if v.count != t.length {
+		v.report(nil, RuleLength, nil, fmt.Sprintf("found %d items, but length is %d", v.count, t.length))
+	}
+
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError[K]{Violations: v.violations}
+}
+
+type validator[K cmp.Ordered, V any] struct {
+	tree       *BTree[K, V]
+	leafDepth  int
+	count      int
+	violations []Violation[K]
+}
+
+func (v *validator[K, V]) report(path []int, rule Rule, keys []K, message string) {
+	v.violations = append(v.violations, Violation[K]{
+		Path:    append([]int{}, path...),
+		Rule:    rule,
+		Keys:    keys,
+		Message: message,
+	})
+}
+
+/*
+Checks the subtree rooted at n, whose keys must lie strictly between lower and upper
+when those are not nil
+*/
+func (v *validator[K, V]) checkNode(n *Node[K, V], path []int, lower, upper *K) {
+	t := v.tree
+	isRoot := len(path) == 0
+	keys := n.keys()
+	v.count += len(n.items)
+
+	if len(n.items) > t.maxItems() {
+		v.report(path, RuleFill, keys, fmt.Sprintf("node has %d items, more than the maximum %d", len(n.items), t.maxItems()))
+	}
+	if !isRoot && len(n.items) < t.minItems() {
+		v.report(path, RuleFill, keys, fmt.Sprintf("node has %d items, fewer than the minimum %d", len(n.items), t.minItems()))
+	}
+	if isRoot && len(n.items) == 0 {
+		v.report(path, RuleFill, keys, "root has no items")
+	}
+
+	for idx := 1; idx < len(keys); idx++ {
+		if t.cmp(keys[idx-1], keys[idx]) >= 0 {
+			v.report(path, RuleOrdering, []K{keys[idx-1], keys[idx]}, "items are not sorted")
+		}
+	}
+	for _, k := range keys {
+		if lower != nil && t.cmp(k, *lower) <= 0 {
+			v.report(path, RuleOrdering, []K{*lower, k}, "item is not larger than its parent separator")
+		}
+		if upper != nil && t.cmp(k, *upper) >= 0 {
+			v.report(path, RuleOrdering, []K{*upper, k}, "item is not smaller than its parent separator")
+		}
+	}
+
+	if n.isLeaf() {
+		depth := len(path)
+		if v.leafDepth == -1 {
+			v.leafDepth = depth
+		} else if depth != v.leafDepth {
+			v.report(path, RuleDepth, keys, fmt.Sprintf("leaf is at depth %d, but others are at depth %d", depth, v.leafDepth))
+		}
+		return
+	}
+
+	if !n.hasValidKeyChildRatio() {
+		v.report(path, RuleChildRatio, keys, fmt.Sprintf("node has %d items and %d children", len(n.items), len(n.children)))
+	}
+
+	for idx, child := range n.children {
+		childLower, childUpper := lower, upper
+		if idx > 0 && idx-1 < len(n.items) {
+			childLower = &n.items[idx-1].key
+		}
+		if idx < len(n.items) {
+			childUpper = &n.items[idx].key
+		}
+		v.checkNode(child, append(path, idx), childLower, childUpper)
+	}
+}
+
+// Returns the keys of the items in node n
+func (n *Node[K, V]) keys() []K {
+	keys := make([]K, len(n.items))
+	for idx, item := range n.items {
+		keys[idx] = item.key
+	}
+	return keys
+}
@@ -0,0 +1,151 @@
+package btree
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func leaf(keys ...int) *Node[int, int] {
+	n := &Node[int, int]{}
+	for _, k := range keys {
+		n.items = append(n.items, Item[int, int]{key: k, value: k})
+	}
+	return n
+}
+
+func internal(keys []int, children ...*Node[int, int]) *Node[int, int] {
+	n := leaf(keys...)
+	n.children = children
+	return n
+}
+
+func treeOf(degree int, root *Node[int, int]) *BTree[int, int] {
+	t := NewBtree[int, int](degree)
+	t.root = root
+	t.length = countItems(root)
+	return t
+}
+
+func countItems(n *Node[int, int]) int {
+	if n == nil {
+		return 0
+	}
+	count := len(n.items)
+	for _, child := range n.children {
+		count += countItems(child)
+	}
+	return count
+}
+
+func TestValidateValidTrees(t *testing.T) {
+	btree := NewBtree[int, int](3)
+	if err := btree.Validate(); err != nil {
+		t.Errorf("Empty tree is invalid: %v", err)
+	}
+
+	for i := range 1000 {
+		btree.Insert(i*7%1000, i)
+	}
+	for i := range 500 {
+		btree.Delete(i * 3 % 1000)
+	}
+	if err := btree.Validate(); err != nil {
+		t.Errorf("Tree built through Insert and Delete is invalid: %v", err)
+	}
+}
+
+func TestValidateViolations(t *testing.T) {
+	tests := []struct {
+		name string
+		tree *BTree[int, int]
+		rule Rule
+		path []int
+		keys []int
+	}{
+		{
+			name: "unsorted leaf",
+			tree: treeOf(2, internal([]int{10}, leaf(1, 5, 3), leaf(20))),
+			rule: RuleOrdering,
+			path: []int{0},
+			keys: []int{5, 3},
+		},
+		{
+			name: "key outside parent range",
+			tree: treeOf(2, internal([]int{10}, leaf(1), leaf(5))),
+			rule: RuleOrdering,
+			path: []int{1},
+			keys: []int{10, 5},
+		},
+		{
+			name: "grandchild outside grandparent range",
+			tree: treeOf(2, internal([]int{50},
+				internal([]int{20}, leaf(10), leaf(60)),
+				internal([]int{70}, leaf(60), leaf(80)),
+			)),
+			rule: RuleOrdering,
+			path: []int{0, 1},
+			keys: []int{50, 60},
+		},
+		{
+			name: "underfull node",
+			tree: treeOf(3, internal([]int{10}, leaf(1, 2), leaf(20))),
+			rule: RuleFill,
+			path: []int{1},
+			keys: []int{20},
+		},
+		{
+			name: "overfull node",
+			tree: treeOf(2, leaf(1, 2, 3, 4)),
+			rule: RuleFill,
+			path: []int{},
+			keys: []int{1, 2, 3, 4},
+		},
+		{
+			name: "uneven leaf depth",
+			tree: treeOf(2, internal([]int{10}, leaf(1), internal([]int{20}, leaf(15), leaf(25)))),
+			rule: RuleDepth,
+			path: []int{1, 0},
+			keys: []int{15},
+		},
+		{
+			name: "missing child",
+			tree: treeOf(2, internal([]int{10, 20}, leaf(1), leaf(15))),
+			rule: RuleChildRatio,
+			path: []int{},
+			keys: []int{10, 20},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.tree.Validate()
+			var verr *ValidationError[int]
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate() = %v; expected a *ValidationError", err)
+			}
+
+			for _, v := range verr.Violations {
+				if v.Rule == test.rule && slices.Equal(v.Path, test.path) && slices.Equal(v.Keys, test.keys) {
+					return
+				}
+			}
+			t.Errorf("Validate() = %v; expected a %v violation at %v with keys %v", err, test.rule, test.path, test.keys)
+		})
+	}
+}
+
+func TestValidateLength(t *testing.T) {
+	btree := treeOf(2, leaf(1, 2))
+	btree.length = 3
+
+	err := btree.Validate()
+	var verr *ValidationError[int]
+	if !errors.As(err, &verr) || len(verr.Violations) != 1 || verr.Violations[0].Rule != RuleLength {
+		t.Errorf("Validate() = %v; expected a single length violation", err)
+	}
+	if !strings.Contains(err.Error(), "length") {
+		t.Errorf("Error() = %q; expected it to name the rule", err.Error())
+	}
+}